@@ -0,0 +1,267 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// ErrInvalidABI is returned for configs whose ABI can't be parsed
+var ErrInvalidABI = errors.New("invalid abi")
+
+// NewABIConfig returns a config which watches scope with the given contract
+// ABI. Events of subscriptions to it carry the decoded contract call, see DecodedCall
+func NewABIConfig(scope string, watchAddress bool, contract abi.ABI) (Config, error) {
+	cfg := NewConfig(scope, watchAddress, nil)
+	return cfg, cfg.SetABI(contract)
+}
+
+// NewABIConfigJSON is like NewABIConfig for an ABI in its JSON encoding
+func NewABIConfigJSON(scope string, watchAddress bool, r io.Reader) (Config, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return Config{}, err
+	}
+	cfg := NewConfig(scope, watchAddress, json.RawMessage(data))
+	if _, err := cfg.Contract(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// SetABI sets the ABI sent with c to contract
+func (c *Config) SetABI(contract abi.ABI) error {
+	data, err := json.Marshal(abiEntries(contract))
+	if err != nil {
+		return err
+	}
+	c.ABI = json.RawMessage(data)
+	_, err = c.Contract()
+	return err
+}
+
+// Contract parses the ABI of c, which may be an abi.ABI, its JSON encoding
+// or any value encoding to it. It returns nil if c has no ABI
+func (c Config) Contract() (*abi.ABI, error) {
+	var data []byte
+	switch v := c.ABI.(type) {
+	case nil:
+		return nil, nil
+	case abi.ABI:
+		data, _ = json.Marshal(abiEntries(v))
+	case *abi.ABI:
+		data, _ = json.Marshal(abiEntries(*v))
+	case json.RawMessage:
+		data = v
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		var err error
+		if data, err = json.Marshal(v); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidABI, err)
+		}
+	}
+	contract, err := abi.JSON(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidABI, err)
+	}
+	if len(contract.Methods) == 0 && len(contract.Events) == 0 {
+		return nil, fmt.Errorf("%w: no methods or events", ErrInvalidABI)
+	}
+	return &contract, nil
+}
+
+// prepareConfig validates the filters and ABI of msg before it is sent.
+// It returns msg with an abi.ABI converted to its JSON form and the parsed
+// ABI, if any
+func prepareConfig(msg Configuration) (Configuration, *abi.ABI, error) {
+	if err := ValidateFilters(msg.Filters); err != nil {
+		return msg, nil, err
+	}
+	contract, err := msg.Contract()
+	if err != nil {
+		return msg, nil, err
+	}
+	switch v := msg.ABI.(type) {
+	case abi.ABI:
+		msg.ABI = abiEntries(v)
+	case *abi.ABI:
+		msg.ABI = abiEntries(*v)
+	}
+	return msg, contract, nil
+}
+
+// DecodedCall is a contract call decoded with the ABI of the config a
+// subscription was created with
+type DecodedCall struct {
+	// Method is the called method
+	Method abi.Method
+	// Args maps argument names to their values, as unpacked by go-ethereum
+	Args   map[string]interface{}
+	values []interface{}
+}
+
+// Name returns the name of the called method as written in the ABI
+func (d *DecodedCall) Name() string {
+	return d.Method.RawName
+}
+
+// Bind copies the arguments into v, a pointer to a struct with a field
+// for every argument or to a single value for methods with one argument
+func (d *DecodedCall) Bind(v interface{}) error {
+	return d.Method.Inputs.Copy(v, d.values)
+}
+
+// DecodeCall decodes the call data input with contract. It returns nil if
+// input is not a call to a method of contract
+func DecodeCall(contract *abi.ABI, input string) (*DecodedCall, error) {
+	data, err := hexutil.Decode(input)
+	if err != nil || len(data) < 4 {
+		return nil, nil
+	}
+	method, err := contract.MethodById(data[:4])
+	if err != nil {
+		return nil, nil
+	}
+	values, err := method.Inputs.Unpack(data[4:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack %s arguments: %w", method.RawName, err)
+	}
+	args := make(map[string]interface{}, len(values))
+	if err := method.Inputs.UnpackIntoMap(args, data[4:]); err != nil {
+		return nil, fmt.Errorf("failed to unpack %s arguments: %w", method.RawName, err)
+	}
+	return &DecodedCall{Method: *method, Args: args, values: values}, nil
+}
+
+// callDecoder is implemented by payloads which can carry a decoded contract call
+type callDecoder interface {
+	decodeCall(contract *abi.ABI) error
+}
+
+func (p *EthTxPayload) decodeCall(contract *abi.ABI) error {
+	call, err := DecodeCall(contract, p.Event.Transaction.Input)
+	if err != nil {
+		return fmt.Errorf("transaction %s: %w", p.Event.Transaction.Hash, err)
+	}
+	p.Event.Call = call
+	return nil
+}
+
+// abiEntry is the JSON form of a single ABI item
+type abiEntry struct {
+	Type            string        `json:"type"`
+	Name            string        `json:"name,omitempty"`
+	Inputs          []abiArgument `json:"inputs"`
+	Outputs         []abiArgument `json:"outputs,omitempty"`
+	StateMutability string        `json:"stateMutability,omitempty"`
+	Constant        bool          `json:"constant,omitempty"`
+	Payable         bool          `json:"payable,omitempty"`
+	Anonymous       bool          `json:"anonymous,omitempty"`
+}
+
+type abiArgument struct {
+	Name         string        `json:"name"`
+	Type         string        `json:"type"`
+	InternalType string        `json:"internalType,omitempty"`
+	Components   []abiArgument `json:"components,omitempty"`
+	Indexed      bool          `json:"indexed,omitempty"`
+}
+
+// abiEntries converts contract back to its JSON form, which abi.ABI lacks
+func abiEntries(contract abi.ABI) []abiEntry {
+	var out []abiEntry
+	method := func(m abi.Method) {
+		typ := "function"
+		switch m.Type {
+		case abi.Constructor:
+			typ = "constructor"
+		case abi.Fallback:
+			typ = "fallback"
+		case abi.Receive:
+			typ = "receive"
+		}
+		out = append(out, abiEntry{
+			Type:            typ,
+			Name:            m.RawName,
+			Inputs:          abiArguments(m.Inputs),
+			Outputs:         abiArguments(m.Outputs),
+			StateMutability: m.StateMutability,
+			Constant:        m.Constant,
+			Payable:         m.Payable,
+		})
+	}
+	// a zero Method is a constructor too, only parsed methods have a string form
+	if contract.Constructor.String() != "" {
+		method(contract.Constructor)
+	}
+	for _, name := range sortedKeys(contract.Methods) {
+		method(contract.Methods[name])
+	}
+	if contract.HasFallback() {
+		method(contract.Fallback)
+	}
+	if contract.HasReceive() {
+		method(contract.Receive)
+	}
+	for _, name := range sortedKeys(contract.Events) {
+		e := contract.Events[name]
+		out = append(out, abiEntry{Type: "event", Name: e.RawName, Inputs: abiArguments(e.Inputs), Anonymous: e.Anonymous})
+	}
+	for _, name := range sortedKeys(contract.Errors) {
+		e := contract.Errors[name]
+		out = append(out, abiEntry{Type: "error", Name: e.Name, Inputs: abiArguments(e.Inputs)})
+	}
+	return out
+}
+
+func abiArguments(args abi.Arguments) []abiArgument {
+	out := make([]abiArgument, len(args))
+	for i, arg := range args {
+		out[i] = abiArgumentOf(arg.Name, arg.Type, arg.Indexed)
+	}
+	return out
+}
+
+// abiArgumentOf spells tuples, and arrays of them, as "tuple" with their
+// fields as components
+func abiArgumentOf(name string, t abi.Type, indexed bool) abiArgument {
+	arg := abiArgument{Name: name, Type: t.String(), Indexed: indexed}
+	elem, suffix := &t, ""
+	for elem.T == abi.SliceTy || elem.T == abi.ArrayTy {
+		if elem.T == abi.SliceTy {
+			suffix = "[]" + suffix
+		} else {
+			suffix = fmt.Sprintf("[%d]", elem.Size) + suffix
+		}
+		elem = elem.Elem
+	}
+	if elem.T != abi.TupleTy {
+		return arg
+	}
+	arg.Type = "tuple" + suffix
+	if elem.TupleRawName != "" {
+		arg.InternalType = "struct " + elem.TupleRawName + suffix
+	}
+	for i, field := range elem.TupleElems {
+		arg.Components = append(arg.Components, abiArgumentOf(elem.TupleRawNames[i], *field, false))
+	}
+	return arg
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
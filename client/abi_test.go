@@ -0,0 +1,115 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ATMackay/go-blocknative/client/blocknativetest"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/require"
+)
+
+const erc20ABI = `[
+	{"type":"constructor","inputs":[{"name":"supply","type":"uint256"}],"stateMutability":"nonpayable"},
+	{"type":"function","name":"transfer","inputs":[{"name":"_to","type":"address"},{"name":"_value","type":"uint256"}],"outputs":[{"name":"","type":"bool"}],"stateMutability":"nonpayable"},
+	{"type":"function","name":"batch","inputs":[{"name":"orders","type":"tuple[2][]","internalType":"struct Order[2][]","components":[{"name":"to","type":"address"},{"name":"amount","type":"uint256"}]}],"outputs":[],"stateMutability":"payable"},
+	{"type":"event","name":"Transfer","inputs":[{"name":"from","type":"address","indexed":true},{"name":"to","type":"address","indexed":true},{"name":"value","type":"uint256"}],"anonymous":false}
+]`
+
+func TestABIConfig(t *testing.T) {
+	contract, err := abi.JSON(strings.NewReader(erc20ABI))
+	require.NoError(t, err)
+	cfg, err := NewABIConfig("0xdac17f958d2ee523a2206206994597c13d831ec7", true, contract)
+	require.NoError(t, err)
+
+	// the ABI survives the trip through its JSON form
+	parsed, err := cfg.Contract()
+	require.NoError(t, err)
+	require.Equal(t, len(contract.Methods), len(parsed.Methods))
+	for name, m := range contract.Methods {
+		require.Equal(t, m.Sig, parsed.Methods[name].Sig)
+		require.Equal(t, m.StateMutability, parsed.Methods[name].StateMutability)
+	}
+	require.Equal(t, contract.Events["Transfer"].Sig, parsed.Events["Transfer"].Sig)
+	require.True(t, parsed.Events["Transfer"].Inputs[0].Indexed)
+	require.Len(t, parsed.Constructor.Inputs, 1)
+
+	fromJSON, err := NewABIConfigJSON("global", false, strings.NewReader(erc20ABI))
+	require.NoError(t, err)
+	_, err = fromJSON.Contract()
+	require.NoError(t, err)
+	_, err = NewABIConfigJSON("global", false, strings.NewReader(`[{"type":"function","name":"f","inputs":[{"name":"a","type":"foo"}]}]`))
+	require.ErrorIs(t, err, ErrInvalidABI)
+	_, err = NewABIConfigJSON("global", false, strings.NewReader(`[]`))
+	require.ErrorIs(t, err, ErrInvalidABI)
+
+	// an abi.ABI set directly is sent in its JSON form
+	msg, _, err := prepareConfig(NewConfiguration(NewBaseMessageMainnet("key"), NewConfig("global", false, contract)))
+	require.NoError(t, err)
+	data, err := json.Marshal(msg)
+	require.NoError(t, err)
+	var sent struct {
+		Config struct {
+			ABI []map[string]interface{} `json:"abi"`
+		} `json:"config"`
+	}
+	require.NoError(t, json.Unmarshal(data, &sent))
+	require.Len(t, sent.Config.ABI, 4)
+}
+
+func TestDecodedCall(t *testing.T) {
+	contract, err := abi.JSON(strings.NewReader(erc20ABI))
+	require.NoError(t, err)
+	cfg, err := NewABIConfig("global", false, contract)
+	require.NoError(t, err)
+	to := common.HexToAddress("0xfa6de2697d59e88ed7fc4dfe5a33dac43565ea41")
+	input, err := contract.Pack("transfer", to, big.NewInt(42))
+	require.NoError(t, err)
+
+	cl, srv := newFakeClient(t, Opts{})
+	sub, err := cl.NewEventSubscription(context.Background(), NewConfiguration(NewBaseMessageMainnet("test-key"), cfg))
+	require.NoError(t, err)
+	for _, input := range []string{hexutil.Encode(input), "0x", hexutil.Encode(input[:10])} {
+		require.NoError(t, srv.SendTx(blocknativetest.TxEvent{Transaction: map[string]interface{}{"hash": "0x01", "input": input}}))
+	}
+
+	next := func() EthTxPayload {
+		t.Helper()
+		select {
+		case ev := <-sub.Events():
+			return ev
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for event")
+		}
+		return EthTxPayload{}
+	}
+	call := next().Event.Call
+	require.NotNil(t, call)
+	require.Equal(t, "transfer", call.Name())
+	require.Equal(t, to, call.Args["_to"])
+	require.Equal(t, big.NewInt(42), call.Args["_value"])
+	var transfer struct {
+		To    common.Address
+		Value *big.Int
+	}
+	require.NoError(t, call.Bind(&transfer))
+	require.Equal(t, to, transfer.To)
+	require.Equal(t, int64(42), transfer.Value.Int64())
+
+	// plain transfers are delivered without a call
+	require.Nil(t, next().Event.Call)
+	// truncated arguments are delivered too, with an error
+	require.Nil(t, next().Event.Call)
+	select {
+	case err := <-sub.Err():
+		require.Contains(t, err.Error(), "transfer")
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for error")
+	}
+}
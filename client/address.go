@@ -0,0 +1,158 @@
+package client
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ValidateAddress checks that address is a valid address on chain. Addresses
+// of systems other than ethereum and bitcoin, and of bitcoin networks other
+// than main and testnet, are not checked. The returned error wraps
+// ErrInvalidAddress
+func ValidateAddress(chain Blockchain, address string) error {
+	var ok bool
+	switch chain.System {
+	case SystemEthereum:
+		ok = common.IsHexAddress(address) && strings.HasPrefix(strings.ToLower(address), "0x")
+	case SystemBitcoin:
+		if _, known := bitcoinPrefixes[chain.Network]; !known {
+			return nil
+		}
+		ok = validBitcoinAddress(chain.Network, address)
+	default:
+		return nil
+	}
+	if !ok {
+		return fmt.Errorf("%q is not a valid %s %s address: %w", address, chain.System, chain.Network, ErrInvalidAddress)
+	}
+	return nil
+}
+
+// bitcoin address prefixes per network: base58check version bytes for
+// P2PKH and P2SH addresses and the bech32 human readable part
+var bitcoinPrefixes = map[string]struct {
+	versions []byte
+	hrp      string
+}{
+	"main":    {versions: []byte{0x00, 0x05}, hrp: "bc"},
+	"testnet": {versions: []byte{0x6f, 0xc4}, hrp: "tb"},
+}
+
+func validBitcoinAddress(network, address string) bool {
+	prefix, ok := bitcoinPrefixes[network]
+	if !ok {
+		return false
+	}
+	if strings.HasPrefix(strings.ToLower(address), prefix.hrp+"1") {
+		return validSegwitAddress(prefix.hrp, address)
+	}
+	version, ok := decodeBase58Check(address)
+	return ok && bytes.IndexByte(prefix.versions, version) >= 0
+}
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// decodeBase58Check decodes a legacy address, returning its version byte
+func decodeBase58Check(s string) (byte, bool) {
+	n := new(big.Int)
+	radix := big.NewInt(58)
+	for _, r := range s {
+		i := strings.IndexRune(base58Alphabet, r)
+		if i < 0 {
+			return 0, false
+		}
+		n.Mul(n, radix).Add(n, big.NewInt(int64(i)))
+	}
+	// leading ones encode leading zero bytes
+	zeros := len(s) - len(strings.TrimLeft(s, "1"))
+	b := append(make([]byte, zeros), n.Bytes()...)
+	if len(b) != 25 {
+		return 0, false
+	}
+	first := sha256.Sum256(b[:21])
+	second := sha256.Sum256(first[:])
+	if !bytes.Equal(second[:4], b[21:]) {
+		return 0, false
+	}
+	return b[0], true
+}
+
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// bech32 checksum constants of BIP-173 (witness version 0) and BIP-350 (later versions)
+const (
+	bech32Const  = 1
+	bech32mConst = 0x2bc830a3
+)
+
+// validSegwitAddress checks a bech32 or bech32m encoded segwit address
+func validSegwitAddress(hrp, address string) bool {
+	if strings.ToLower(address) != address && strings.ToUpper(address) != address {
+		// mixed case is not allowed
+		return false
+	}
+	address = strings.ToLower(address)
+	pos := strings.LastIndexByte(address, '1')
+	if address[:pos] != hrp || len(address)-pos-1 < 7 || len(address) > 90 {
+		return false
+	}
+	data := make([]byte, 0, len(address)-pos-1)
+	for _, r := range address[pos+1:] {
+		i := strings.IndexRune(bech32Charset, r)
+		if i < 0 {
+			return false
+		}
+		data = append(data, byte(i))
+	}
+	version := data[0]
+	check := bech32Polymod(append(bech32ExpandHRP(hrp), data...))
+	if version == 0 && check != bech32Const || version > 0 && check != bech32mConst || version > 16 {
+		return false
+	}
+	// the witness program is the 5 bit groups between the version and the checksum
+	bits := (len(data) - 7) * 5
+	if bits%8 >= 5 {
+		return false
+	}
+	// the bits left over after the last byte are padding and must be zero (BIP-173)
+	if pad := uint(bits % 8); data[len(data)-7]&(1<<pad-1) != 0 {
+		return false
+	}
+	size := bits / 8
+	if size < 2 || size > 40 || version == 0 && size != 20 && size != 32 {
+		return false
+	}
+	return true
+}
+
+func bech32ExpandHRP(hrp string) []byte {
+	out := make([]byte, 0, 2*len(hrp)+1)
+	for i := 0; i < len(hrp); i++ {
+		out = append(out, hrp[i]>>5)
+	}
+	out = append(out, 0)
+	for i := 0; i < len(hrp); i++ {
+		out = append(out, hrp[i]&31)
+	}
+	return out
+}
+
+func bech32Polymod(values []byte) uint32 {
+	gen := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (top>>uint(i))&1 == 1 {
+				chk ^= gen[i]
+			}
+		}
+	}
+	return chk
+}
@@ -0,0 +1,162 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/params"
+)
+
+var (
+	weiPerGwei  = big.NewInt(params.GWei)
+	weiPerEther = big.NewInt(params.Ether)
+)
+
+// BigInt is an arbitrary precision integer used for the numeric fields of
+// blocknative payloads. It unmarshals from JSON numbers, decimal strings and
+// 0x prefixed hex strings and marshals to a decimal string. Empty strings
+// and null leave it unset, which marshals back to null
+type BigInt struct {
+	v *big.Int
+}
+
+// NewBigInt returns a BigInt holding a copy of x
+func NewBigInt(x *big.Int) BigInt {
+	if x == nil {
+		return BigInt{}
+	}
+	return BigInt{v: new(big.Int).Set(x)}
+}
+
+// IsSet reports whether the value was present in the payload
+func (b BigInt) IsSet() bool {
+	return b.v != nil
+}
+
+// Int returns a copy of the value, zero if it is unset
+func (b BigInt) Int() *big.Int {
+	if b.v == nil {
+		return new(big.Int)
+	}
+	return new(big.Int).Set(b.v)
+}
+
+// Uint64 returns the value as a uint64, the result is undefined if it doesn't fit
+func (b BigInt) Uint64() uint64 {
+	if b.v == nil {
+		return 0
+	}
+	return b.v.Uint64()
+}
+
+// String returns the decimal representation of the value
+func (b BigInt) String() string {
+	if b.v == nil {
+		return "0"
+	}
+	return b.v.String()
+}
+
+// Gwei converts an amount in wei to gwei without losing precision
+func (b BigInt) Gwei() *big.Rat {
+	return ToGwei(b.Int())
+}
+
+// Ether converts an amount in wei to ether without losing precision
+func (b BigInt) Ether() *big.Rat {
+	return ToEther(b.Int())
+}
+
+// MarshalJSON implements json.Marshaler
+func (b BigInt) MarshalJSON() ([]byte, error) {
+	if b.v == nil {
+		return []byte("null"), nil
+	}
+	return json.Marshal(b.v.String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (b *BigInt) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		b.v = nil
+		return nil
+	}
+	s := string(data)
+	if len(data) > 0 && data[0] == '"' {
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+	}
+	v, err := parseBigInt(s)
+	if err != nil {
+		return err
+	}
+	b.v = v
+	return nil
+}
+
+func parseBigInt(s string) (*big.Int, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+	neg := strings.HasPrefix(s, "-")
+	digits := strings.TrimPrefix(s, "-")
+	base := 10
+	if strings.HasPrefix(digits, "0x") || strings.HasPrefix(digits, "0X") {
+		digits, base = digits[2:], 16
+	}
+	v, ok := new(big.Int).SetString(digits, base)
+	if !ok {
+		// numbers may be sent in exponent notation, accept them when they are integral
+		r, ok := new(big.Rat).SetString(s)
+		if !ok || !r.IsInt() {
+			return nil, fmt.Errorf("invalid integer %q", s)
+		}
+		return r.Num(), nil
+	}
+	if neg {
+		v.Neg(v)
+	}
+	return v, nil
+}
+
+// ToGwei converts an amount in wei to gwei without losing precision
+func ToGwei(wei *big.Int) *big.Rat {
+	return new(big.Rat).SetFrac(wei, weiPerGwei)
+}
+
+// ToEther converts an amount in wei to ether without losing precision
+func ToEther(wei *big.Int) *big.Rat {
+	return new(big.Rat).SetFrac(wei, weiPerEther)
+}
+
+// GweiToWei converts an amount in gwei to wei, truncating fractions of a wei
+func GweiToWei(gwei *big.Rat) *big.Int {
+	return ratToInt(new(big.Rat).Mul(gwei, new(big.Rat).SetInt(weiPerGwei)))
+}
+
+// EtherToWei converts an amount in ether to wei, truncating fractions of a wei
+func EtherToWei(ether *big.Rat) *big.Int {
+	return ratToInt(new(big.Rat).Mul(ether, new(big.Rat).SetInt(weiPerEther)))
+}
+
+// FloatToWei converts an amount in units of 10^decimals wei, such as a
+// gas price in gwei (9) or a balance in ether (18), to wei
+func FloatToWei(amount *big.Float, decimals int) *big.Int {
+	r, _ := amount.Rat(nil)
+	if r == nil {
+		// amount is infinite
+		return new(big.Int)
+	}
+	unit := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	return ratToInt(r.Mul(r, new(big.Rat).SetInt(unit)))
+}
+
+func ratToInt(r *big.Rat) *big.Int {
+	return new(big.Int).Quo(r.Num(), r.Denom())
+}
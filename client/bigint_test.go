@@ -0,0 +1,66 @@
+package client
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBigIntJSON(t *testing.T) {
+	huge, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	for _, tc := range []struct {
+		in   string
+		want *big.Int
+	}{
+		{`"123456789012345678901234567890"`, huge},
+		{`123456789012345678901234567890`, huge},
+		{`"0x18ee90ff6c373e0ee4e3f0ad2"`, huge},
+		{`21000`, big.NewInt(21000)},
+		{`"-1000"`, big.NewInt(-1000)},
+		{`1e3`, big.NewInt(1000)},
+	} {
+		var b BigInt
+		require.NoError(t, json.Unmarshal([]byte(tc.in), &b), tc.in)
+		require.True(t, b.IsSet())
+		require.Equal(t, 0, tc.want.Cmp(b.Int()), tc.in)
+
+		out, err := json.Marshal(b)
+		require.NoError(t, err)
+		require.Equal(t, `"`+tc.want.String()+`"`, string(out))
+	}
+
+	for _, in := range []string{`""`, `null`} {
+		var b BigInt
+		require.NoError(t, json.Unmarshal([]byte(in), &b))
+		require.False(t, b.IsSet())
+		require.Equal(t, int64(0), b.Int().Int64())
+	}
+
+	for _, in := range []string{`"1.5"`, `"abc"`, `true`} {
+		var b BigInt
+		require.Error(t, json.Unmarshal([]byte(in), &b), in)
+	}
+}
+
+func TestUnitConversion(t *testing.T) {
+	wei, _ := new(big.Int).SetString("1234567890123456789", 10)
+	require.Equal(t, "1.234567890123456789", ToEther(wei).FloatString(18))
+	require.Equal(t, "1234567890.123456789", ToGwei(wei).FloatString(9))
+	require.Equal(t, 0, wei.Cmp(EtherToWei(ToEther(wei))))
+	require.Equal(t, 0, wei.Cmp(GweiToWei(ToGwei(wei))))
+
+	gwei, _ := new(big.Rat).SetString("1.5")
+	require.Equal(t, big.NewInt(1500000000), GweiToWei(gwei))
+	require.Equal(t, big.NewInt(1500000000), FloatToWei(big.NewFloat(1.5), 9))
+}
+
+func TestParseGas(t *testing.T) {
+	var msg EthTxPayload
+	require.NoError(t, json.Unmarshal([]byte(`{"event":{"transaction":{"maxFeePerGas":"30000000001","maxPriorityFeePerGas":"1500000000"}}}`), &msg))
+	maxFee, tip, err := ParseGas(&msg)
+	require.NoError(t, err)
+	require.Equal(t, "30.000000001", maxFee.FloatString(9))
+	require.Equal(t, "1.5", tip.FloatString(1))
+}
@@ -0,0 +1,109 @@
+package client
+
+import (
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+)
+
+// Blockchain systems supported by blocknative
+const (
+	SystemEthereum = "ethereum"
+	SystemBitcoin  = "bitcoin"
+)
+
+var satoshiPerBitcoin = big.NewRat(100000000, 1)
+
+// NewBitcoinBaseMessage returns a base message for the bitcoin network
+// blocknative calls network, "main" or "testnet" unless others are
+// registered with RegisterNetwork
+func NewBitcoinBaseMessage(apiKey, network string) (BaseMessage, error) {
+	if apiKey == "" {
+		apiKey = os.Getenv("BLOCKNATIVE_DAPP_ID")
+	}
+	n, err := NetworkByName(SystemBitcoin, network)
+	if err != nil {
+		return BaseMessage{}, err
+	}
+	return BaseMessage{
+		Timestamp:  time.Now(),
+		DappID:     apiKey,
+		Blockchain: n.Blockchain(),
+	}, nil
+}
+
+// BtcTxPayload is the payload of a bitcoin transaction event. Subscribe to
+// it with the generic functions, e.g.
+//
+//	SubscribeAddress[BtcTxPayload](ctx, c, address, OnBitcoin("main"))
+type BtcTxPayload struct {
+	Version       int       `json:"version"`
+	ServerVersion string    `json:"serverVersion"`
+	TimeStamp     time.Time `json:"timeStamp"`
+	ConnectionID  string    `json:"connectionId"`
+	Status        string    `json:"status"`
+	Event         struct {
+		BaseMessage
+		Transaction BtcTransaction `json:"transaction"`
+	} `json:"event"`
+}
+
+// BtcTransaction is a bitcoin transaction as reported by blocknative.
+// Amounts are decimal strings in bitcoin, see BtcToSatoshi
+type BtcTransaction struct {
+	TxID              string             `json:"txid"`
+	System            string             `json:"system"`
+	Network           string             `json:"network"`
+	Status            TxStatus           `json:"status"`
+	BlockHeight       int                `json:"blockHeight"`
+	TimeStamp         time.Time          `json:"timeStamp"`
+	Inputs            []BtcTxIO          `json:"inputs"`
+	Outputs           []BtcTxIO          `json:"outputs"`
+	Fee               string             `json:"fee"`
+	NetBalanceChanges []BtcBalanceChange `json:"netBalanceChanges"`
+	WatchedAddress    string             `json:"watchedAddress"`
+	Direction         string             `json:"direction"`
+	Counterparty      string             `json:"counterparty"`
+}
+
+// BtcTxIO is an input or output of a bitcoin transaction
+type BtcTxIO struct {
+	Address string `json:"address"`
+	Value   string `json:"value"`
+}
+
+// BtcBalanceChange is the change in balance of an address caused by a bitcoin transaction
+type BtcBalanceChange struct {
+	Address string `json:"address"`
+	Delta   string `json:"delta"`
+}
+
+// BtcToSatoshi converts a decimal amount of bitcoin to satoshis
+func BtcToSatoshi(amount string) (*big.Int, error) {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(amount))
+	if !ok {
+		return nil, fmt.Errorf("invalid bitcoin amount %q", amount)
+	}
+	r.Mul(r, satoshiPerBitcoin)
+	if !r.IsInt() {
+		return nil, fmt.Errorf("bitcoin amount %q has fractions of a satoshi", amount)
+	}
+	return r.Num(), nil
+}
+
+// OnBitcoin is like OnNetwork for the bitcoin network blocknative calls
+// network, "main" or "testnet" unless others are registered with
+// RegisterNetwork
+func OnBitcoin(network string) SubscribeOption {
+	return func(o *subscribeOptions) error {
+		n, err := NetworkByName(SystemBitcoin, network)
+		if err != nil {
+			return err
+		}
+		b := n.Blockchain()
+		o.blockchain = &b
+		return nil
+	}
+}
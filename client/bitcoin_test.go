@@ -0,0 +1,125 @@
+package client
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ATMackay/go-blocknative/client/blocknativetest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateAddress(t *testing.T) {
+	ethereum := Blockchain{System: SystemEthereum, Network: "main"}
+	bitcoin := Blockchain{System: SystemBitcoin, Network: "main"}
+	testnet := Blockchain{System: SystemBitcoin, Network: "testnet"}
+	for _, tc := range []struct {
+		chain   Blockchain
+		address string
+		valid   bool
+	}{
+		{ethereum, "0xfa6de2697d59e88ed7fc4dfe5a33dac43565ea41", true},
+		{ethereum, "0xFA6DE2697D59E88ED7FC4DFE5A33DAC43565EA41", true},
+		{ethereum, "fa6de2697d59e88ed7fc4dfe5a33dac43565ea41", false},
+		{ethereum, "0xfa6de2697d59e88ed7fc4dfe5a33dac43565ea4", false},
+		{ethereum, "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", false},
+		{bitcoin, "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", true},
+		{bitcoin, "3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy", true},
+		{bitcoin, "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNb", false},
+		{bitcoin, "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", true},
+		{bitcoin, "BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4", true},
+		{bitcoin, "bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0", true},
+		{bitcoin, "bc1qqqqsyqcyq5rqwzqfpg9scrgwpugpzysnzs23v9ccrydpk8qarc0szrtjt7", true},
+		// the same witness program with a non-zero padding bit and a valid checksum
+		{bitcoin, "bc1qqqqsyqcyq5rqwzqfpg9scrgwpugpzysnzs23v9ccrydpk8qarc03l4l8kv", false},
+		{bitcoin, "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t5", false},
+		{bitcoin, "bc1Qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", false},
+		{bitcoin, "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx", false},
+		{bitcoin, "0xfa6de2697d59e88ed7fc4dfe5a33dac43565ea41", false},
+		{testnet, "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx", true},
+		{testnet, "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", false},
+		{Blockchain{System: "other", Network: "main"}, "anything", true},
+	} {
+		err := ValidateAddress(tc.chain, tc.address)
+		if tc.valid {
+			require.NoError(t, err, tc.address)
+		} else {
+			require.ErrorIs(t, err, ErrInvalidAddress, tc.address)
+		}
+	}
+}
+
+func TestBitcoinRegistryKeys(t *testing.T) {
+	const address = "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa"
+	bitcoin := Blockchain{System: SystemBitcoin, Network: "main"}
+	ethereum := Blockchain{System: SystemEthereum, Network: "main"}
+	r := newRegistry()
+	btc := NewSubscription[BtcTxPayload](address)
+	require.True(t, r.add(btc, bitcoin, KindAddress))
+	eth := NewSubscription[EthTxPayload]("0xFA6DE2697D59E88ED7FC4DFE5A33DAC43565EA41")
+	require.True(t, r.add(eth, ethereum, KindAddress))
+
+	// base58 addresses are case-sensitive, hex addresses are not
+	require.Equal(t, btc, r.find(bitcoin, address))
+	require.Nil(t, r.find(bitcoin, strings.ToLower(address)))
+	require.Equal(t, eth, r.find(ethereum, "0xfa6de2697d59e88ed7fc4dfe5a33dac43565ea41"))
+	// the systems have networks of the same name
+	require.True(t, r.add(NewSubscription[BtcTxPayload]("global"), bitcoin, KindConfig))
+	require.True(t, r.add(NewSubscription[EthTxPayload]("global"), ethereum, KindConfig))
+}
+
+func TestBtcToSatoshi(t *testing.T) {
+	sats, err := BtcToSatoshi("0.00012345")
+	require.NoError(t, err)
+	require.Equal(t, int64(12345), sats.Int64())
+	sats, err = BtcToSatoshi("-1.5")
+	require.NoError(t, err)
+	require.Equal(t, int64(-150000000), sats.Int64())
+	_, err = BtcToSatoshi("0.000000001")
+	require.Error(t, err)
+	_, err = BtcToSatoshi("one")
+	require.Error(t, err)
+}
+
+func TestBitcoinSubscription(t *testing.T) {
+	const address = "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx"
+	_, err := NewBitcoinBaseMessage("key", "regtest")
+	require.ErrorIs(t, err, ErrInvalidNetwork)
+	base, err := NewBitcoinBaseMessage("key", "testnet")
+	require.NoError(t, err)
+	require.Equal(t, Blockchain{System: SystemBitcoin, Network: "testnet"}, base.Blockchain)
+
+	cl, srv := newFakeClient(t, Opts{})
+	_, err = SubscribeAddress[BtcTxPayload](context.Background(), cl, address)
+	require.ErrorIs(t, err, ErrInvalidAddress)
+	sub, err := SubscribeAddress[BtcTxPayload](context.Background(), cl, address, OnBitcoin("testnet"))
+	require.NoError(t, err)
+	received := srv.Received()
+	require.Equal(t, "bitcoin", received[len(received)-1].Blockchain.System)
+
+	require.NoError(t, srv.SendTx(blocknativetest.TxEvent{
+		Blockchain: blocknativetest.Blockchain{System: "bitcoin", Network: "testnet"},
+		Transaction: map[string]interface{}{
+			"txid":           "abcd",
+			"status":         "pending",
+			"watchedAddress": address,
+			"inputs":         []map[string]string{{"address": "tb1qinput", "value": "0.5"}},
+			"outputs":        []map[string]string{{"address": address, "value": "0.4999"}},
+			"fee":            "0.0001",
+		},
+	}))
+	select {
+	case ev := <-sub.Events():
+		tx := ev.Event.Transaction
+		require.Equal(t, "abcd", tx.TxID)
+		require.Equal(t, TxStatus("pending"), tx.Status)
+		require.Len(t, tx.Inputs, 1)
+		require.Equal(t, address, tx.Outputs[0].Address)
+		fee, err := BtcToSatoshi(tx.Fee)
+		require.NoError(t, err)
+		require.Equal(t, int64(10000), fee.Int64())
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for event")
+	}
+}
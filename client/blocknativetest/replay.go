@@ -0,0 +1,96 @@
+package blocknativetest
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// recordedFrame is a line of a recording written by client.Recorder
+type recordedFrame struct {
+	Time      time.Time       `json:"time"`
+	Direction string          `json:"direction"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// NewReplayServer starts a server which plays a recording made with
+// client.Recorder back to every client that connects.
+//
+// Inbound frames of the recording are sent to the client in order, spaced
+// by their original delays divided by speed; a speed of zero sends them as
+// fast as possible. Each outbound frame makes the replay wait until the
+// client sends a message, so acknowledgements follow the requests they
+// answer. Messages the client sends are recorded as on any other Server.
+// Once the recording is exhausted the connection stays open until the
+// client hangs up
+func NewReplayServer(recording io.Reader, speed float64) (*Server, error) {
+	var script []recordedFrame
+	sc := bufio.NewScanner(recording)
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; sc.Scan(); line++ {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var f recordedFrame
+		if err := json.Unmarshal(sc.Bytes(), &f); err != nil {
+			return nil, fmt.Errorf("recording line %d: %w", line, err)
+		}
+		if f.Direction != "in" && f.Direction != "out" {
+			return nil, fmt.Errorf("recording line %d: unknown direction %q", line, f.Direction)
+		}
+		script = append(script, f)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if speed < 0 {
+		return nil, fmt.Errorf("invalid replay speed %v", speed)
+	}
+	s := NewServer()
+	s.script = append([]recordedFrame{}, script...)
+	s.speed = speed
+	return s, nil
+}
+
+// replay plays the script to c
+func (s *Server) replay(c *conn) {
+	var last time.Time
+	for _, f := range s.script {
+		if !last.IsZero() && s.speed > 0 {
+			time.Sleep(time.Duration(float64(f.Time.Sub(last)) / s.speed))
+		}
+		last = f.Time
+		if f.Direction == "out" {
+			if !s.readMessage(c) {
+				return
+			}
+			continue
+		}
+		c.mtx.Lock()
+		err := c.WriteMessage(websocket.TextMessage, f.Data)
+		c.mtx.Unlock()
+		if err != nil {
+			return
+		}
+	}
+	for s.readMessage(c) {
+	}
+}
+
+// readMessage records the next message sent by c, returning false once c is closed
+func (s *Server) readMessage(c *conn) bool {
+	_, data, err := c.ReadMessage()
+	if err != nil {
+		return false
+	}
+	if msg, err := decodeMessage(data, c.id); err == nil {
+		s.mtx.Lock()
+		s.record(msg)
+		s.mtx.Unlock()
+	}
+	return true
+}
@@ -0,0 +1,494 @@
+// Package blocknativetest provides an in-process fake of blocknative's
+// websocket api for testing clients without network access.
+//
+// The server speaks enough of the protocol for client.Client: it sends the
+// connect response, acknowledges checkDappId, accountAddress,
+// activeTransaction and configs messages by echoing them back, records
+// everything it receives and lets tests script events, errors and
+// disconnects.
+package blocknativetest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Message is a message received from a client
+type Message struct {
+	CategoryCode string          `json:"categoryCode"`
+	EventCode    string          `json:"eventCode"`
+	DappID       string          `json:"dappId"`
+	Blockchain   Blockchain      `json:"blockchain"`
+	Account      *Account        `json:"account,omitempty"`
+	Transaction  *Transaction    `json:"transaction,omitempty"`
+	Config       *Config         `json:"config,omitempty"`
+	Raw          json.RawMessage `json:"-"`
+	// Conn is the sequence number of the connection the message arrived on, starting at 1
+	Conn int `json:"-"`
+}
+
+// Blockchain identifies the system and network of a message or event
+type Blockchain struct {
+	System  string `json:"system"`
+	Network string `json:"network"`
+}
+
+// Account is the address of an accountAddress message
+type Account struct {
+	Address string `json:"address"`
+}
+
+// Transaction is the hash of an activeTransaction message
+type Transaction struct {
+	Hash string `json:"hash"`
+}
+
+// Config is the scope of a configs message, the remaining fields are kept in Message.Raw
+type Config struct {
+	Scope string `json:"scope"`
+}
+
+// Key returns the address, hash or scope the message refers to
+func (m Message) Key() string {
+	switch {
+	case m.Account != nil:
+		return m.Account.Address
+	case m.Transaction != nil:
+		return m.Transaction.Hash
+	case m.Config != nil:
+		return m.Config.Scope
+	}
+	return ""
+}
+
+// TxEvent describes a transaction event sent by the server
+type TxEvent struct {
+	// EventCode defaults to txPool
+	EventCode string
+	// Blockchain defaults to ethereum main
+	Blockchain Blockchain
+	// Transaction holds the fields of event.transaction, such as hash,
+	// watchedAddress, status or value
+	Transaction map[string]interface{}
+	// ContractCall is sent as event.contractCall when set
+	ContractCall map[string]interface{}
+}
+
+// watch identifies something watched on one connection
+type watch struct {
+	conn    int
+	network string
+	key     string
+}
+
+type rejection struct {
+	categoryCode string
+	key          string
+	reason       string
+}
+
+// Server is a fake blocknative websocket server
+type Server struct {
+	// APIKey, when set, is the only dappId accepted by checkDappId
+	APIKey string
+
+	srv      *httptest.Server
+	upgrader websocket.Upgrader
+
+	mtx        sync.Mutex
+	conns      map[*conn]bool
+	connCount  int
+	refuse     bool
+	received   []Message
+	notify     chan struct{} // closed and replaced whenever a message is received
+	watched    map[watch]Message
+	rejections []rejection
+
+	// script and speed are set for servers created by NewReplayServer
+	script []recordedFrame
+	speed  float64
+}
+
+type conn struct {
+	*websocket.Conn
+	id  int
+	mtx sync.Mutex // serializes writes
+}
+
+func (c *conn) writeJSON(v interface{}) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.WriteJSON(v)
+}
+
+// NewServer starts a fake blocknative server. Close it when done
+func NewServer() *Server {
+	s := &Server{
+		conns:   make(map[*conn]bool),
+		notify:  make(chan struct{}),
+		watched: make(map[watch]Message),
+	}
+	s.srv = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// Host returns the host:port the server listens on, suitable for client.Opts
+func (s *Server) Host() string {
+	u, _ := url.Parse(s.srv.URL)
+	return u.Host
+}
+
+// URL returns the websocket url of the server
+func (s *Server) URL() string {
+	return "ws://" + s.Host()
+}
+
+// Close disconnects all clients and shuts the server down
+func (s *Server) Close() {
+	s.Disconnect()
+	s.srv.Close()
+}
+
+// Disconnect drops every open connection without a close handshake,
+// simulating a network failure. New connections are still accepted
+func (s *Server) Disconnect() {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	for c := range s.conns {
+		c.UnderlyingConn().Close()
+	}
+}
+
+// Drop closes the connection with the given id (see Message.Conn),
+// reporting whether it was open
+func (s *Server) Drop(id int) bool {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	for c := range s.conns {
+		if c.id == id {
+			c.UnderlyingConn().Close()
+			return true
+		}
+	}
+	return false
+}
+
+// Refuse makes the server reject new connections until called with false
+func (s *Server) Refuse(refuse bool) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.refuse = refuse
+}
+
+// Connections returns the number of open connections
+func (s *Server) Connections() int {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return len(s.conns)
+}
+
+// Reject makes the server answer messages of categoryCode for key (an
+// address, hash or scope, empty for any) with an error frame carrying reason
+func (s *Server) Reject(categoryCode, key, reason string) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.rejections = append(s.rejections, rejection{categoryCode: categoryCode, key: strings.ToLower(key), reason: reason})
+}
+
+// Received returns every message received so far, in order
+func (s *Server) Received() []Message {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return append([]Message(nil), s.received...)
+}
+
+// Watched returns the keys currently watched by accountAddress,
+// activeTransaction and configs messages across all open connections
+func (s *Server) Watched() []string {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	seen := make(map[string]bool)
+	keys := make([]string, 0, len(s.watched))
+	for w := range s.watched {
+		if !seen[w.key] {
+			seen[w.key] = true
+			keys = append(keys, w.key)
+		}
+	}
+	return keys
+}
+
+// WaitFor blocks until a message matching match has been received, checking
+// messages received earlier first
+func (s *Server) WaitFor(ctx context.Context, match func(Message) bool) (Message, error) {
+	seen := 0
+	for {
+		s.mtx.Lock()
+		received, notify := s.received[seen:], s.notify
+		seen = len(s.received)
+		s.mtx.Unlock()
+		for _, msg := range received {
+			if match(msg) {
+				return msg, nil
+			}
+		}
+		select {
+		case <-notify:
+		case <-ctx.Done():
+			return Message{}, ctx.Err()
+		}
+	}
+}
+
+// WaitForMessage waits up to timeout for a message with the given codes and key (empty for any)
+func (s *Server) WaitForMessage(timeout time.Duration, categoryCode, eventCode, key string) (Message, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	msg, err := s.WaitFor(ctx, func(m Message) bool {
+		return m.CategoryCode == categoryCode && m.EventCode == eventCode &&
+			(key == "" || strings.EqualFold(m.Key(), key))
+	})
+	if err != nil {
+		return msg, fmt.Errorf("waiting for %s/%s %s: %w", categoryCode, eventCode, key, err)
+	}
+	return msg, nil
+}
+
+// Send writes frame to every open connection
+func (s *Server) Send(frame interface{}) error {
+	return s.send(frame, func(*conn) bool { return true })
+}
+
+// SendTx sends a transaction event to the connections watching its
+// watchedAddress, hash or bitcoin txid, or with a global config. When no connection
+// watches the transaction it is sent to every open connection
+func (s *Server) SendTx(ev TxEvent) error {
+	var keys []string
+	for _, field := range []string{"watchedAddress", "hash", "txid"} {
+		if key, ok := ev.Transaction[field].(string); ok && key != "" {
+			keys = append(keys, strings.ToLower(key))
+		}
+	}
+	keys = append(keys, "global")
+	network := ev.Blockchain.Network
+	if network == "" {
+		network = "main"
+	}
+	s.mtx.Lock()
+	watching := make(map[int]bool)
+	for w := range s.watched {
+		for _, key := range keys {
+			if w.key == key && w.network == network {
+				watching[w.conn] = true
+			}
+		}
+	}
+	s.mtx.Unlock()
+	return s.send(ev.Frame(), func(c *conn) bool {
+		return len(watching) == 0 || watching[c.id]
+	})
+}
+
+func (s *Server) send(frame interface{}, to func(*conn) bool) error {
+	s.mtx.Lock()
+	conns := make([]*conn, 0, len(s.conns))
+	for c := range s.conns {
+		if to(c) {
+			conns = append(conns, c)
+		}
+	}
+	s.mtx.Unlock()
+	if len(conns) == 0 {
+		return fmt.Errorf("no open connections")
+	}
+	for _, c := range conns {
+		if err := c.writeJSON(frame); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SendError sends an error frame to every open connection. event, if not
+// nil, is sent as the event the error refers to
+func (s *Server) SendError(reason string, event interface{}) error {
+	frame := map[string]interface{}{
+		"version":   0,
+		"timeStamp": time.Now().UTC(),
+		"status":    "error",
+		"reason":    reason,
+	}
+	if event != nil {
+		frame["event"] = event
+	}
+	return s.Send(frame)
+}
+
+// Frame returns the frame blocknative sends for ev
+func (ev TxEvent) Frame() map[string]interface{} {
+	if ev.EventCode == "" {
+		ev.EventCode = "txPool"
+	}
+	if ev.Blockchain.System == "" {
+		ev.Blockchain.System = "ethereum"
+	}
+	if ev.Blockchain.Network == "" {
+		ev.Blockchain.Network = "main"
+	}
+	tx := map[string]interface{}{}
+	for k, v := range ev.Transaction {
+		tx[k] = v
+	}
+	event := map[string]interface{}{
+		"timeStamp":    time.Now().UTC(),
+		"categoryCode": "activeTransaction",
+		"eventCode":    ev.EventCode,
+		"dappId":       "",
+		"blockchain":   ev.Blockchain,
+		"transaction":  tx,
+	}
+	if ev.ContractCall != nil {
+		event["contractCall"] = ev.ContractCall
+	}
+	return map[string]interface{}{
+		"version":       0,
+		"serverVersion": "blocknativetest",
+		"timeStamp":     time.Now().UTC(),
+		"connectionId":  "",
+		"status":        "ok",
+		"event":         event,
+	}
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	s.mtx.Lock()
+	refuse := s.refuse
+	s.mtx.Unlock()
+	if refuse {
+		http.Error(w, "connections refused", http.StatusServiceUnavailable)
+		return
+	}
+	ws, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	s.mtx.Lock()
+	s.connCount++
+	c := &conn{Conn: ws, id: s.connCount}
+	s.conns[c] = true
+	s.mtx.Unlock()
+	defer func() {
+		s.mtx.Lock()
+		delete(s.conns, c)
+		for w := range s.watched {
+			if w.conn == c.id {
+				delete(s.watched, w)
+			}
+		}
+		s.mtx.Unlock()
+		ws.Close()
+	}()
+
+	if s.script != nil {
+		s.replay(c)
+		return
+	}
+	if err := c.writeJSON(map[string]interface{}{
+		"connectionId":  fmt.Sprintf("conn-%d", c.id),
+		"serverVersion": "blocknativetest",
+		"showUX":        false,
+		"status":        "ok",
+		"version":       0,
+	}); err != nil {
+		return
+	}
+	for {
+		_, data, err := ws.ReadMessage()
+		if err != nil {
+			return
+		}
+		msg, err := decodeMessage(data, c.id)
+		if err != nil {
+			c.writeJSON(map[string]interface{}{"status": "error", "reason": "invalid json: " + err.Error()})
+			continue
+		}
+		if err := c.writeJSON(s.handle(msg)); err != nil {
+			return
+		}
+	}
+}
+
+// handle records msg and returns the acknowledgement
+func (s *Server) handle(msg Message) map[string]interface{} {
+	var event interface{}
+	json.Unmarshal(msg.Raw, &event)
+	ack := map[string]interface{}{
+		"version":   0,
+		"timeStamp": time.Now().UTC(),
+		"status":    "ok",
+		"event":     event,
+	}
+	fail := func(reason string) map[string]interface{} {
+		ack["status"], ack["reason"] = "error", reason
+		return ack
+	}
+
+	s.mtx.Lock()
+	defer func() {
+		s.record(msg)
+		s.mtx.Unlock()
+	}()
+	key := strings.ToLower(msg.Key())
+	for _, r := range s.rejections {
+		if r.categoryCode == msg.CategoryCode && (r.key == "" || r.key == key) {
+			return fail(r.reason)
+		}
+	}
+	switch msg.CategoryCode {
+	case "initialize":
+		if s.APIKey != "" && msg.DappID != s.APIKey {
+			return fail(msg.DappID + " is not a valid API key")
+		}
+	case "accountAddress", "activeTransaction", "configs":
+		if key == "" {
+			return fail("missing address, hash or scope")
+		}
+		w := watch{conn: msg.Conn, network: msg.Blockchain.Network, key: key}
+		switch msg.EventCode {
+		case "watch", "txSent", "put":
+			s.watched[w] = msg
+		case "unwatch":
+			delete(s.watched, w)
+		default:
+			return fail("unknown eventCode " + msg.EventCode)
+		}
+	default:
+		return fail("unknown categoryCode " + msg.CategoryCode)
+	}
+	return ack
+}
+
+func decodeMessage(data []byte, connID int) (Message, error) {
+	var msg Message
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return msg, err
+	}
+	msg.Raw, msg.Conn = data, connID
+	return msg, nil
+}
+
+// record appends msg to the received messages, s.mtx must be held
+func (s *Server) record(msg Message) {
+	s.received = append(s.received, msg)
+	close(s.notify)
+	s.notify = make(chan struct{})
+}
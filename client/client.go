@@ -2,10 +2,11 @@ package client
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
-	"net/url"
 	"sync"
+	"time"
 
 	"github.com/gorilla/websocket"
 )
@@ -17,6 +18,21 @@ type Opts struct {
 	Path                 string
 	APIKey               string
 	PrintConnectResponse bool
+	// Reconnect enables redialing blocknative when the connection drops.
+	// The session is restored by resending the initialization message
+	// and replaying the subscriptions recorded in the message history
+	Reconnect bool
+	Backoff   Backoff
+	// PingInterval is how often the client pings blocknative, zero uses
+	// DefaultPingInterval and a negative value disables the heartbeat.
+	// The connection is considered stale when nothing, including a pong,
+	// arrives within PingInterval+PongTimeout
+	PingInterval time.Duration
+	PongTimeout  time.Duration
+	// Recorder, when set, records every frame sent and received by the client
+	Recorder *Recorder
+	// Pacing limits the rate at which WatchAddresses subscribes
+	Pacing Pacing
 }
 
 // ConnectResponse is the message we receive when opening a connection to the API
@@ -29,65 +45,70 @@ type ConnectResponse struct {
 	Version       int    `json:"version"`
 }
 
-// Client wraps gorilla websocket connections
+// Client wraps gorilla websocket connections. A single read loop owns the
+// connection and routes every inbound frame to the matching subscription
 type Client struct {
-	conn                 *websocket.Conn
-	ctx                  context.Context
-	cancel               context.CancelFunc
-	initMsg              BaseMessage // used to resend the initialization msg if connection drops
-	apiKey               string
-	mtx                  sync.RWMutex
-	subscriptionRegistry map[string]Subscription
+	conn      *websocket.Conn
+	ctx       context.Context
+	cancel    context.CancelFunc
+	opts      Opts
+	initMsg   BaseMessage // used to resend the initialization msg if connection drops
+	history   MsgHistory  // subscribe and config messages replayed after a reconnect
+	apiKey    string
+	mtx       sync.RWMutex // guards initMsg, acks and err
+	wmtx      sync.Mutex   // serializes writes to conn
+	hmtx      sync.Mutex   // orders history updates with unsubscribing
+	registry  *registry
+	acks      []*ackWaiter
+	unmatched chan []byte
+	done      chan struct{} // closed once the read loop exits
+	err       error         // reason the read loop exited
 }
 
 // New returns a new blocknative websocket client
 func New(ctx context.Context, opts Opts) (*Client, error) {
 	ctx, cancel := context.WithCancel(ctx)
-	u := url.URL{
-		Scheme: opts.Scheme,
-		Host:   opts.Host,
-		Path:   opts.Path,
-	}
-	c, _, err := websocket.DefaultDialer.DialContext(ctx, u.String(), nil)
+	c, out, err := dial(ctx, opts)
 	if err != nil {
 		cancel()
 		return nil, err
 	}
-	// this checks out connection to blocknative's api and makes sure that we connected properly
-	var out ConnectResponse
-	if err := c.ReadJSON(&out); err != nil {
-		cancel()
-		return nil, err
-	}
-	if out.Status != "ok" {
-		cancel()
-		return nil, fmt.Errorf("failed to initialize websockets connection reason: %v", out.Reason)
-	}
 	if opts.PrintConnectResponse {
-		log.Printf("%+v\n", out)
+		log.Printf("%+v\n", *out)
 	}
-	return &Client{conn: c, ctx: ctx, cancel: cancel, apiKey: opts.APIKey, subscriptionRegistry: make(map[string]Subscription)}, nil
+	cl := &Client{
+		conn:      c,
+		ctx:       ctx,
+		cancel:    cancel,
+		opts:      opts,
+		apiKey:    opts.APIKey,
+		registry:  newRegistry(),
+		unmatched: make(chan []byte, unmatchedBuffer),
+		done:      make(chan struct{}),
+	}
+	cl.keepalive(c)
+	go cl.readLoop()
+	go cl.heartbeat()
+	return cl, nil
 }
 
 // Initialize is used to handle blocknative websockets api initialization
 // note we set CategoryCode and EventCode ourselves.
 func (c *Client) Initialize(msg BaseMessage) error {
-	c.mtx.Lock()
-	defer c.mtx.Unlock()
 	msg.Version = "1"
 	msg.CategoryCode = "initialize"
-	msg.EventCode = "checkDappId"
+	msg.EventCode = EventCheckDappID
+	c.mtx.Lock()
 	c.initMsg = msg
-	if err := c.conn.WriteJSON(&msg); err != nil {
-		return err
-	}
-	var out ConnectResponse
-	err := c.conn.ReadJSON(&out)
+	c.mtx.Unlock()
+	out, err := c.request(c.ctx, &msg, func(hdr *frameHeader) bool {
+		return hdr.Event.EventCode == EventCheckDappID || hdr.Event.CategoryCode == ""
+	})
 	if err != nil {
 		return err
 	}
-	if out.Status != "ok" {
-		return fmt.Errorf("failed to initialize api connection reason:%v", out.Reason)
+	if out.failed() {
+		return fmt.Errorf("failed to initialize api connection: %w", serverError(out))
 	}
 	return nil
 }
@@ -97,94 +118,356 @@ func (c *Client) APIKey() string {
 	return c.apiKey
 }
 
-// SubscriptionRegistry returns the chached subscription map
-func (c *Client) SubscriptionRegistry() map[string]Subscription {
-	return c.subscriptionRegistry
+// Subscription returns the subscription registered under key. The key is
+// looked up on the network the client was initialized with unless OnNetwork
+// or OnChain is given. An address watched both directly and by a config
+// scoped to it yields the address subscription
+func (c *Client) Subscription(key string, opts ...SubscribeOption) (AnySubscription, bool) {
+	info, ok := c.SubscriptionInfo(key, opts...)
+	return info.Subscription, ok
 }
 
-// NewEventSubscription creates an event subscription.
-func (c *Client) NewEventSubscription(msg Configuration) error {
-	if err := c.WriteJSON(&msg); err != nil {
-		return err
-	}
-	var out ConnectResponse
-	if err := c.ReadJSON(&out); err != nil {
-		return err
-	}
-	if out.Status != "ok" {
-		return fmt.Errorf("failed to create subscription reason:%v", out.Reason)
+// SubscriptionInfo returns the registry entry for key, see Subscription
+func (c *Client) SubscriptionInfo(key string, opts ...SubscribeOption) (SubscriptionInfo, bool) {
+	base, err := c.base(opts)
+	if err != nil {
+		return SubscriptionInfo{}, false
 	}
+	return c.registry.get(base.Blockchain, key)
+}
 
-	key := msg.Scope
-	c.subscriptionRegistry[key] = NewSubscription(key)
-	go eventLoop(c, c.subscriptionRegistry[key], NewEventUnsubscribe(c.initMsg, msg.Config))
+// HasSubscription reports whether a subscription is registered under key, see Subscription
+func (c *Client) HasSubscription(key string, opts ...SubscribeOption) bool {
+	_, ok := c.SubscriptionInfo(key, opts...)
+	return ok
+}
 
-	return nil
+// Subscriptions returns a snapshot of the registered subscriptions, oldest first
+func (c *Client) Subscriptions() []SubscriptionInfo {
+	return c.registry.snapshot()
+}
+
+// Unmatched returns the frames which could not be routed to a subscription
+// or pending request. Frames are dropped when the channel is full
+func (c *Client) Unmatched() <-chan []byte {
+	return c.unmatched
+}
+
+// NewEventSubscription creates an event subscription. It waits for blocknative
+// to accept the configuration and stays active until ctx is cancelled or the
+// subscription is unsubscribed, after which the config is unwatched
+func (c *Client) NewEventSubscription(ctx context.Context, msg Configuration, opts ...SubscribeOption) (*Subscription[EthTxPayload], error) {
+	return SubscribeConfig[EthTxPayload](ctx, c, msg, opts...)
 }
 
 // NewAddressSubscription creates a new subscription to blocknative
 // for monitoring address activity. the subscription is added to the
 // client's subscription registry which contains an event channel
-// for watched events provided by blocknative servers
-func (c *Client) NewAddressSubscription(address string) error {
-	if err := c.WriteJSON(NewAddressSubscribe(
-		c.initMsg,
-		address,
-	)); err != nil {
+// for watched events provided by blocknative servers. Cancelling ctx
+// unsubscribes and tells blocknative to stop watching the address.
+// The address is watched on the network the client was initialized with
+// unless OnNetwork or OnChain is given, and must be valid on that network's
+// system, see ValidateAddress
+func (c *Client) NewAddressSubscription(ctx context.Context, address string, opts ...SubscribeOption) (*Subscription[EthTxPayload], error) {
+	return SubscribeAddress[EthTxPayload](ctx, c, address, opts...)
+}
+
+// NewTransactionSubscription creates a new subscription for monitoring
+// a transaction by supplied transaction ID until ctx is cancelled
+func (c *Client) NewTransactionSubscription(ctx context.Context, txHash string, opts ...SubscribeOption) (*Subscription[EthTxPayload], error) {
+	return SubscribeTransaction[EthTxPayload](ctx, c, txHash, opts...)
+}
+
+// SubscribeConfig is like Client.NewEventSubscription but decodes events into T.
+// The config is watched on the network of msg, unless it is empty or
+// OnNetwork or OnChain is given. Its filters are checked with ValidateFilters
+// and its ABI parsed before it is sent. If the config has an ABI the
+// contract calls of EthTxPayload events are decoded into Event.Call
+func SubscribeConfig[T any](ctx context.Context, c *Client, msg Configuration, opts ...SubscribeOption) (*Subscription[T], error) {
+	msg, contract, err := prepareConfig(msg)
+	if err != nil {
+		return nil, err
+	}
+	base, err := c.base(opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(opts) > 0 || msg.Network == "" {
+		msg.Blockchain = base.Blockchain
+	}
+	base.Blockchain = msg.Blockchain
+	sub := NewSubscription[T](msg.Scope)
+	sub.contract = contract
+	if err := c.subscribe(ctx, sub, msg, NewEventUnsubscribe(base, msg.Config)); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// SubscribeAddress is like Client.NewAddressSubscription but decodes events into T
+func SubscribeAddress[T any](ctx context.Context, c *Client, address string, opts ...SubscribeOption) (*Subscription[T], error) {
+	base, err := c.base(opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateAddress(base.Blockchain, address); err != nil {
+		return nil, err
+	}
+	sub := NewSubscription[T](address)
+	if err := c.subscribe(ctx, sub, NewAddressSubscribe(base, address), NewAddressUnsubscribe(base, address)); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// SubscribeTransaction is like Client.NewTransactionSubscription but decodes events into T
+func SubscribeTransaction[T any](ctx context.Context, c *Client, txHash string, opts ...SubscribeOption) (*Subscription[T], error) {
+	base, err := c.base(opts)
+	if err != nil {
+		return nil, err
+	}
+	sub := NewSubscription[T](txHash)
+	if err := c.subscribe(ctx, sub, NewTxSubscribe(base, txHash), NewTxUnsubscribe(base, txHash)); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// subscribe confirms sub with blocknative and registers it until ctx is done
+func (c *Client) subscribe(ctx context.Context, sub AnySubscription, subMsg, unsubMsg interface{}) error {
+	if err := c.confirm(ctx, sub, subMsg, unsubMsg); err != nil {
 		return err
 	}
-	c.subscriptionRegistry[address] = NewSubscription(address)
-	go eventLoop(c, c.subscriptionRegistry[address], NewAddressUnsubscribe(c.initMsg, address))
+	go c.unsubscribeWhenDone(ctx, sub)
 	return nil
 }
 
-// NewTransactionSubscription creates a new subscription for monitoring
-// a transaction by supplied transaction ID
-func (c *Client) NewTransactionSubscription(txHash string) error {
-	if err := c.WriteJSON(NewTxSubscribe(
-		c.initMsg,
-		txHash,
-	)); err != nil {
+// unsubscribeWhenDone unsubscribes sub once ctx is done, unless it is closed first
+func (c *Client) unsubscribeWhenDone(ctx context.Context, sub AnySubscription) {
+	select {
+	case <-ctx.Done():
+		// ctx is already done, so wait for the acknowledgement on the client's context
+		sub.UnsubscribeContext(c.ctx)
+	case <-sub.closed():
+	}
+}
+
+// confirm sends subMsg and waits until blocknative acknowledges it, or
+// ctx is done, then records it in the message history. sub is registered
+// before the message is sent so that no event following the acknowledgement
+// is missed, and removed again on failure. It can be unsubscribed, sending
+// unsubMsg, as soon as it is registered
+func (c *Client) confirm(ctx context.Context, sub AnySubscription, subMsg, unsubMsg interface{}) error {
+	base := messageBase(subMsg)
+	c.track(sub, subMsg, unsubMsg)
+	prev := c.registry.put(sub, base.Blockchain, messageKind(subMsg))
+	if err := c.acknowledged(ctx, sub, subMsg); err != nil {
+		if !c.registry.restore(sub, prev) && prev != nil {
+			// sub was unsubscribed while waiting, which unwatched the key of prev too
+			prev.Subscription.close()
+		}
 		return err
 	}
-	c.subscriptionRegistry[txHash] = NewSubscription(txHash)
-	go eventLoop(c, c.subscriptionRegistry[txHash], NewTxUnsubscribe(c.initMsg, txHash))
+	if prev != nil {
+		// the replacement keeps watching the same key, so we don't notify blocknative
+		prev.Subscription.close()
+	}
+	c.record(sub, subMsg)
 	return nil
 }
 
+// confirmNew is like confirm but fails with ErrAlreadySubscribed rather
+// than replace a subscription registered for the same key
+func (c *Client) confirmNew(ctx context.Context, sub AnySubscription, subMsg, unsubMsg interface{}) error {
+	base := messageBase(subMsg)
+	c.track(sub, subMsg, unsubMsg)
+	if !c.registry.add(sub, base.Blockchain, messageKind(subMsg)) {
+		return fmt.Errorf("%s on %s: %w", sub.Key(), base.Network, ErrAlreadySubscribed)
+	}
+	if err := c.acknowledged(ctx, sub, subMsg); err != nil {
+		c.registry.remove(sub)
+		return err
+	}
+	c.record(sub, subMsg)
+	return nil
+}
+
+// acknowledged sends subMsg and waits for blocknative to accept it
+func (c *Client) acknowledged(ctx context.Context, sub AnySubscription, subMsg interface{}) error {
+	base := messageBase(subMsg)
+	out, err := c.request(ctx, subMsg, ackMatcher(base.EventCode, base.Blockchain, sub.Key()))
+	if err != nil {
+		return err
+	}
+	if out.failed() {
+		return fmt.Errorf("failed to create subscription: %w", serverError(out))
+	}
+	return nil
+}
+
+// track arranges for unsubMsg to be sent to blocknative when sub is
+// unsubscribed. It must be called before sub is registered
+func (c *Client) track(sub AnySubscription, subMsg, unsubMsg interface{}) {
+	key, chain := sub.Key(), messageBase(subMsg).Blockchain
+	id := messageID(subMsg)
+	sub.setUnsubscribe(func(ctx context.Context) error {
+		if !c.unregister(sub) {
+			// sub was replaced or already removed, whoever replaced it
+			// still watches the key
+			return nil
+		}
+		c.hmtx.Lock()
+		c.history.Remove(func(msg interface{}) bool {
+			return messageID(msg) == id
+		})
+		c.hmtx.Unlock()
+		out, err := c.request(ctx, unsubMsg, ackMatcher(EventUnwatch, chain, key))
+		if err != nil {
+			return err
+		}
+		if out.failed() {
+			return fmt.Errorf("failed to unsubscribe: %w", serverError(out))
+		}
+		return nil
+	})
+}
+
+// record keeps subMsg in the message history, so the subscription is
+// restored after a reconnect, unless sub was unsubscribed or replaced
+func (c *Client) record(sub AnySubscription, subMsg interface{}) {
+	id := messageID(subMsg)
+	c.hmtx.Lock()
+	defer c.hmtx.Unlock()
+	if !c.registry.has(sub) {
+		return
+	}
+	c.history.Remove(func(msg interface{}) bool {
+		return messageID(msg) == id
+	})
+	c.history.Push(subMsg)
+}
+
+// unregister removes sub from the registry, reporting whether it was the
+// subscription registered for its key
+func (c *Client) unregister(sub AnySubscription) bool {
+	return c.registry.remove(sub)
+}
+
+// messageID returns the registry entry targeted by a subscribe message
+func messageID(msg interface{}) string {
+	key := messageKey(msg)
+	if key == "" {
+		return ""
+	}
+	return entryKey(messageBase(msg).Blockchain, messageKind(msg), key)
+}
+
+// messageKey returns the address, hash or scope watched by a subscribe message
+func messageKey(msg interface{}) string {
+	switch m := msg.(type) {
+	case AddressSubscribe:
+		return m.Address
+	case TxSubscribe:
+		return m.Hash
+	case Configuration:
+		return m.Scope
+	}
+	return ""
+}
+
+// messageBase returns the BaseMessage of a subscribe message
+func messageBase(msg interface{}) BaseMessage {
+	switch m := msg.(type) {
+	case AddressSubscribe:
+		return m.BaseMessage
+	case TxSubscribe:
+		return m.BaseMessage
+	case Configuration:
+		return m.BaseMessage
+	}
+	return BaseMessage{}
+}
+
+// messageKind returns the kind of subscription created by a subscribe message
+func messageKind(msg interface{}) SubscriptionKind {
+	switch msg.(type) {
+	case AddressSubscribe:
+		return KindAddress
+	case TxSubscribe:
+		return KindTransaction
+	}
+	return KindConfig
+}
+
+// validateMessage checks the address or filters of a subscribe message
+// before it is sent
+func validateMessage(msg interface{}) error {
+	switch m := msg.(type) {
+	case AddressSubscribe:
+		return ValidateAddress(m.Blockchain, m.Address)
+	case Configuration:
+		return ValidateFilters(m.Filters)
+	}
+	return nil
+}
+
+func (c *Client) baseMessage() BaseMessage {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+	return c.initMsg
+}
+
 // KillSubscription unsubscribes from the subscription in the registry
 // at the index supplied. Killing the subscription releases the resource for the Client
-// as well notifying block native servers to not monitor for events tracked by the subscription
-func (c *Client) KillSubscription(key string) {
-	sub, ok := c.subscriptionRegistry[key]
+// as well notifying block native servers to not monitor for events tracked by the subscription.
+// It waits for blocknative to acknowledge the unsubscribe until ctx is done
+func (c *Client) KillSubscription(ctx context.Context, key string, opts ...SubscribeOption) error {
+	sub, ok := c.Subscription(key, opts...)
 	if !ok {
-		// no subscription found
-		return
+		return ErrUnknownSubscription
 	}
-	sub.Unsubscribe()
-	delete(c.subscriptionRegistry, key)
+	return sub.UnsubscribeContext(ctx)
 }
 
-// ReadJSON is a wrapper around Conn:ReadJSON
+// ReadJSON reads the next frame which was not routed to a subscription
+// (see Unmatched) and stores it in the value pointed to by out
 func (c *Client) ReadJSON(out interface{}) error {
-	c.mtx.RLock()
-	defer c.mtx.RUnlock()
-	return c.conn.ReadJSON(out)
+	data, ok := <-c.unmatched
+	if !ok {
+		return c.readErr()
+	}
+	return json.Unmarshal(data, out)
 }
 
 // WriteJSON is a wrapper around Conn:WriteJSON
 func (c *Client) WriteJSON(out interface{}) error {
-	c.mtx.Lock()
-	defer c.mtx.Unlock()
-	return c.conn.WriteJSON(out)
+	c.wmtx.Lock()
+	defer c.wmtx.Unlock()
+	return c.writeFrame(c.conn, out)
+}
+
+// writeFrame marshals v and writes it to conn, recording it when enabled
+func (c *Client) writeFrame(conn *websocket.Conn, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	c.opts.Recorder.Record(Outbound, data)
+	return conn.WriteMessage(websocket.TextMessage, data)
 }
 
 // Close is used to terminate our websocket client
 func (c *Client) Close() error {
-	err := c.conn.WriteMessage(
+	// cancelling first stops a reconnect from installing a connection we won't close
+	c.cancel()
+	c.wmtx.Lock()
+	conn := c.conn
+	err := conn.WriteMessage(
 		websocket.CloseMessage,
 		websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
 	)
-	c.cancel()
+	c.wmtx.Unlock()
+	conn.Close()
+	<-c.done
 	return err
 }
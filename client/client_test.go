@@ -4,12 +4,18 @@ import (
 	"context"
 	"testing"
 
+	"github.com/ATMackay/go-blocknative/client/blocknativetest"
 	"github.com/stretchr/testify/require"
 )
 
 func TestClient(t *testing.T) {
+	srv := blocknativetest.NewServer()
+	defer srv.Close()
+	srv.APIKey = "test-key"
+	t.Setenv("BLOCKNATIVE_DAPP_ID", srv.APIKey)
+
 	ctx := context.TODO()
-	client, err := New(ctx, Opts{Scheme: "wss", Host: "api.blocknative.com", Path: "/v0", PrintConnectResponse: true})
+	client, err := New(ctx, Opts{Scheme: "ws", Host: srv.Host(), Path: "/v0", PrintConnectResponse: true, APIKey: srv.APIKey})
 	require.NoError(t, err)
 
 	// test base message creation deriving the api key from an environment variable
@@ -38,6 +44,25 @@ func TestClient(t *testing.T) {
 	require.NoError(t, client.ReadJSON(&out))
 	t.Log(out)
 	require.NoError(t, client.Close())
+
+	received := srv.Received()
+	require.Len(t, received, 3)
+	for i, codes := range [][2]string{{"initialize", "checkDappId"}, {"accountAddress", "watch"}, {"configs", "put"}} {
+		require.Equal(t, codes[0], received[i].CategoryCode)
+		require.Equal(t, codes[1], received[i].EventCode)
+		require.Equal(t, srv.APIKey, received[i].DappID)
+	}
+}
+
+func TestInvalidAPIKey(t *testing.T) {
+	srv := blocknativetest.NewServer()
+	defer srv.Close()
+	srv.APIKey = "test-key"
+
+	client, err := New(context.Background(), Opts{Scheme: "ws", Host: srv.Host()})
+	require.NoError(t, err)
+	defer client.Close()
+	require.ErrorIs(t, client.Initialize(NewBaseMessageMainnet("wrong-key")), ErrInvalidAPIKey)
 }
 
 var (
@@ -79,3 +104,15 @@ var (
 		"type": "event"
 	}`
 )
+
+// newFakeClient returns an initialized Client connected to a fake blocknative server
+func newFakeClient(t *testing.T, opts Opts) (*Client, *blocknativetest.Server) {
+	srv := blocknativetest.NewServer()
+	t.Cleanup(srv.Close)
+	opts.Scheme, opts.Host = "ws", srv.Host()
+	cl, err := New(context.Background(), opts)
+	require.NoError(t, err)
+	t.Cleanup(func() { cl.Close() })
+	require.NoError(t, cl.Initialize(NewBaseMessageMainnet("test-key")))
+	return cl, srv
+}
@@ -0,0 +1,53 @@
+package client
+
+// ContractCall is the decoded contract invocation blocknative attaches to
+// events when the watched config includes an ABI
+type ContractCall struct {
+	ContractType     string `json:"contractType,omitempty"`
+	ContractAddress  string `json:"contractAddress"`
+	ContractName     string `json:"contractName,omitempty"`
+	ContractDecimals int    `json:"contractDecimals,omitempty"`
+	DecimalValue     string `json:"decimalValue,omitempty"`
+	MethodName       string `json:"methodName"`
+	// Params maps argument names to their values, numbers are encoded as decimal strings
+	Params map[string]interface{} `json:"params"`
+}
+
+// NetBalanceChange lists the balance changes of a single address
+// caused by a simulated transaction
+type NetBalanceChange struct {
+	Address        string          `json:"address"`
+	BalanceChanges []BalanceChange `json:"balanceChanges"`
+}
+
+// BalanceChange is the change in balance of one asset
+type BalanceChange struct {
+	Delta     BigInt            `json:"delta"`
+	Asset     Asset             `json:"asset"`
+	Breakdown []BalanceTransfer `json:"breakdown"`
+}
+
+// Asset identifies ether or a token
+type Asset struct {
+	Type            string `json:"type"`
+	Symbol          string `json:"symbol"`
+	ContractAddress string `json:"contractAddress,omitempty"`
+}
+
+// BalanceTransfer is one of the transfers making up a BalanceChange
+type BalanceTransfer struct {
+	Counterparty string `json:"counterparty"`
+	Amount       BigInt `json:"amount"`
+}
+
+// InternalTransaction is a message call made while executing a simulated transaction
+type InternalTransaction struct {
+	Type         string        `json:"type"`
+	From         string        `json:"from"`
+	To           string        `json:"to"`
+	Input        string        `json:"input"`
+	Gas          BigInt        `json:"gas"`
+	GasUsed      BigInt        `json:"gasUsed"`
+	Value        BigInt        `json:"value"`
+	ContractCall *ContractCall `json:"contractCall,omitempty"`
+}
@@ -0,0 +1,65 @@
+package client
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeSimulatedPayload(t *testing.T) {
+	payload := []byte(`{
+		"status": "ok",
+		"event": {
+			"eventCode": "txPoolSimulation",
+			"contractCall": {
+				"contractType": "Uniswap V2: Router 2",
+				"contractAddress": "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+				"methodName": "swapExactETHForTokens",
+				"params": {
+					"amountOutMin": "1000",
+					"path": ["0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2", "0x6b175474e89094c44da98b954eedeac495271d0f"]
+				}
+			},
+			"transaction": {
+				"hash": "0x01",
+				"netBalanceChanges": [{
+					"address": "0xfa6de2697d59e88ed7fc4dfe5a33dac43565ea41",
+					"balanceChanges": [{
+						"delta": "-1000000000000000000",
+						"asset": {"type": "ether", "symbol": "ETH"},
+						"breakdown": [{"counterparty": "0x7a250d5630b4cf539739df2c5dacb4c659f2488d", "amount": "1000000000000000000"}]
+					}]
+				}],
+				"internalTransactions": [{
+					"type": "CALL",
+					"from": "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+					"to": "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2",
+					"gas": 21000,
+					"gasUsed": 1200,
+					"value": "1000000000000000000",
+					"contractCall": {"methodName": "deposit", "params": {}}
+				}]
+			}
+		}
+	}`)
+	var ev EthTxPayload
+	require.NoError(t, json.Unmarshal(payload, &ev))
+
+	call := ev.Event.ContractCall
+	require.NotNil(t, call)
+	require.Equal(t, "swapExactETHForTokens", call.MethodName)
+	require.Equal(t, "1000", call.Params["amountOutMin"])
+	require.Len(t, call.Params["path"], 2)
+
+	changes := ev.Event.Transaction.NetBalanceChanges
+	require.Len(t, changes, 1)
+	require.Equal(t, "-1000000000000000000", changes[0].BalanceChanges[0].Delta.String())
+	require.Equal(t, "ETH", changes[0].BalanceChanges[0].Asset.Symbol)
+	require.Len(t, changes[0].BalanceChanges[0].Breakdown, 1)
+
+	internal := ev.Event.Transaction.InternalTransactions
+	require.Len(t, internal, 1)
+	require.Equal(t, "deposit", internal[0].ContractCall.MethodName)
+	require.Equal(t, uint64(1200), internal[0].GasUsed.Uint64())
+}
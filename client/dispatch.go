@@ -0,0 +1,273 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+var (
+	// unmatchedBuffer is the number of unrouted frames held for ReadJSON/Unmatched
+	// before further unrouted frames are dropped
+	unmatchedBuffer = 128
+	// ackTimeout bounds how long we wait for the server to acknowledge a message
+	ackTimeout = 10 * time.Second
+)
+
+// frameHeader holds the fields of an inbound frame needed to route it
+type frameHeader struct {
+	Status string `json:"status"`
+	Reason string `json:"reason"`
+	Event  struct {
+		CategoryCode string     `json:"categoryCode"`
+		EventCode    EventCode  `json:"eventCode"`
+		Blockchain   Blockchain `json:"blockchain"`
+		Transaction  *struct {
+			Hash           string `json:"hash"`
+			TxID           string `json:"txid"`
+			WatchedAddress string `json:"watchedAddress"`
+		} `json:"transaction"`
+		Config *struct {
+			Scope string `json:"scope"`
+		} `json:"config"`
+		Account *struct {
+			Address string `json:"address"`
+		} `json:"account"`
+	} `json:"event"`
+}
+
+// acknowledges reports whether the frame echoes a message sent by the client
+// rather than carrying a transaction event
+func (hdr *frameHeader) acknowledges() bool {
+	switch hdr.Event.EventCode {
+	case EventCheckDappID, EventWatch, EventUnwatch, EventPut, EventTxSent:
+		return true
+	}
+	return false
+}
+
+// failed reports whether the frame is an error frame
+func (hdr *frameHeader) failed() bool {
+	return hdr.Status == "error"
+}
+
+// keys returns the subscription keys a frame may refer to
+func (hdr *frameHeader) keys() []string {
+	var keys []string
+	if tx := hdr.Event.Transaction; tx != nil {
+		keys = append(keys, tx.Hash)
+	}
+	if acc := hdr.Event.Account; acc != nil {
+		keys = append(keys, acc.Address)
+	}
+	if cfg := hdr.Event.Config; cfg != nil {
+		keys = append(keys, cfg.Scope)
+	}
+	return keys
+}
+
+// ackWaiter is a pending request waiting for the server to acknowledge it
+type ackWaiter struct {
+	match func(*frameHeader) bool
+	ch    chan *frameHeader
+}
+
+// readLoop is the only reader of the websocket connection. Every frame is
+// read once and routed to the subscription or pending request it belongs to.
+// When reconnecting is enabled a dropped connection is replaced in place
+func (c *Client) readLoop() {
+	var err error
+	defer func() { c.shutdown(err) }()
+	for {
+		var data []byte
+		c.extendDeadline(c.conn)
+		if _, data, err = c.conn.ReadMessage(); err != nil {
+			if c.ctx.Err() != nil {
+				return
+			}
+			err = staleErr(err)
+			if !c.opts.Reconnect {
+				return
+			}
+			// subscribers only hear about the dropped connection if it can't be restored
+			if err = c.reconnect(err); err != nil {
+				return
+			}
+			continue
+		}
+		c.opts.Recorder.Record(Inbound, data)
+		c.dispatch(data)
+	}
+}
+
+// dispatch routes a single frame. Transaction events are matched by watched
+// address first, as blocknative emits one event per watch, then by hash and
+// finally by a global config scope. Anything else is offered to pending
+// acknowledgements and otherwise lands on the unmatched channel
+func (c *Client) dispatch(data []byte) {
+	var hdr frameHeader
+	if err := json.Unmarshal(data, &hdr); err != nil {
+		c.deliverUnmatched(data)
+		return
+	}
+	if hdr.failed() {
+		c.dispatchError(&hdr, data)
+		return
+	}
+	if tx := hdr.Event.Transaction; tx != nil && !hdr.acknowledges() {
+		sub := c.registry.lookup(c.frameChain(&hdr), tx.WatchedAddress, tx.Hash, tx.TxID, "global")
+		if sub == nil {
+			c.deliverUnmatched(data)
+			return
+		}
+		sub.deliver(c.ctx, data)
+		return
+	}
+	if !c.resolveAck(&hdr) {
+		c.deliverUnmatched(data)
+	}
+}
+
+// dispatchError delivers an error frame to the request or subscription it
+// refers to. Errors which can't be attributed to a single subscription,
+// such as an invalid api key or rate limiting, are reported to all of them
+func (c *Client) dispatchError(hdr *frameHeader, data []byte) {
+	if c.resolveAck(hdr) {
+		return
+	}
+	err := serverError(hdr)
+	if sub := c.registry.find(c.frameChain(hdr), hdr.keys()...); sub != nil {
+		sub.fail(err)
+		return
+	}
+	c.broadcast(err)
+	c.deliverUnmatched(data)
+}
+
+func (c *Client) resolveAck(hdr *frameHeader) bool {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	for i, w := range c.acks {
+		if w.match(hdr) {
+			c.acks = append(c.acks[:i], c.acks[i+1:]...)
+			w.ch <- hdr
+			return true
+		}
+	}
+	return false
+}
+
+// broadcast reports err to every registered subscription
+func (c *Client) broadcast(err error) {
+	for _, sub := range c.registry.subscriptions() {
+		sub.fail(err)
+	}
+}
+
+func (c *Client) deliverUnmatched(data []byte) {
+	select {
+	case c.unmatched <- data:
+	default:
+		// nobody is draining unmatched frames
+	}
+}
+
+// frameChain returns the blockchain a frame refers to. Frames without a
+// network are attributed to the blockchain the client was initialized with
+func (c *Client) frameChain(hdr *frameHeader) Blockchain {
+	if hdr.Event.Blockchain.Network != "" {
+		return hdr.Event.Blockchain
+	}
+	return c.baseMessage().Blockchain
+}
+
+// ackMatcher matches the acknowledgement of a message with the given event
+// code watching key on chain. Acknowledgements which don't name a system or
+// network match any
+func ackMatcher(code EventCode, chain Blockchain, key string) func(*frameHeader) bool {
+	key = registryKey(chain.System, key)
+	return func(hdr *frameHeader) bool {
+		if hdr.Event.EventCode != code {
+			return false
+		}
+		if s := hdr.Event.Blockchain.System; s != "" && chain.System != "" && s != chain.System {
+			return false
+		}
+		if n := hdr.Event.Blockchain.Network; n != "" && n != chain.Network {
+			return false
+		}
+		for _, k := range hdr.keys() {
+			if registryKey(chain.System, k) == key {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// request writes msg and waits for the frame acknowledging it. The wait is
+// bounded by ctx, or by ackTimeout when ctx has no deadline
+func (c *Client) request(ctx context.Context, msg interface{}, match func(*frameHeader) bool) (*frameHeader, error) {
+	w := &ackWaiter{match: match, ch: make(chan *frameHeader, 1)}
+	c.mtx.Lock()
+	c.acks = append(c.acks, w)
+	c.mtx.Unlock()
+	defer c.removeAck(w)
+
+	if err := c.WriteJSON(msg); err != nil {
+		return nil, err
+	}
+	var timeout <-chan time.Time
+	if _, ok := ctx.Deadline(); !ok {
+		timer := time.NewTimer(ackTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case hdr := <-w.ch:
+		return hdr, nil
+	case <-c.done:
+		return nil, c.readErr()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-timeout:
+		return nil, errors.New("timed out waiting for acknowledgement")
+	}
+}
+
+func (c *Client) removeAck(w *ackWaiter) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	for i := range c.acks {
+		if c.acks[i] == w {
+			c.acks = append(c.acks[:i], c.acks[i+1:]...)
+			return
+		}
+	}
+}
+
+// shutdown tears down all subscriptions once the connection is gone.
+// Subscribers are told why unless the client was closed deliberately
+func (c *Client) shutdown(err error) {
+	c.mtx.Lock()
+	c.err = err
+	c.acks = nil
+	c.mtx.Unlock()
+	subs := c.registry.clear()
+
+	for _, sub := range subs {
+		if c.ctx.Err() == nil {
+			sub.fail(err)
+		}
+		sub.close()
+	}
+	close(c.unmatched)
+	close(c.done)
+}
+
+func (c *Client) readErr() error {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+	return c.err
+}
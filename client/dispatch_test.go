@@ -0,0 +1,100 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/ATMackay/go-blocknative/client/blocknativetest"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestClient connects a Client to a websocket server running serve
+// after the connect response has been sent
+func newTestClient(t *testing.T, opts Opts, serve func(conn *websocket.Conn)) *Client {
+	var upgrader websocket.Upgrader
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		if err := conn.WriteJSON(ConnectResponse{Status: "ok"}); err != nil {
+			return
+		}
+		serve(conn)
+	}))
+	t.Cleanup(srv.Close)
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+	opts.Scheme, opts.Host = "ws", u.Host
+	cl, err := New(context.Background(), opts)
+	require.NoError(t, err)
+	t.Cleanup(func() { cl.Close() })
+	return cl
+}
+
+// acknowledge echoes every message received on conn back as its acknowledgement
+func acknowledge(conn *websocket.Conn) {
+	for {
+		var msg map[string]interface{}
+		if err := conn.ReadJSON(&msg); err != nil {
+			return
+		}
+		if err := conn.WriteJSON(map[string]interface{}{"status": "ok", "event": msg}); err != nil {
+			return
+		}
+	}
+}
+
+func TestDispatch(t *testing.T) {
+	const (
+		addrA  = "0xfa6de2697D59E88Ed7Fc4dFE5A33daC43565ea41"
+		addrB  = "0xdac17f958d2ee523a2206206994597c13d831ec7"
+		txHash = "0x9d2ab0ef5a0d2d6a6e6c8d2cd1f9c1f5b0e4cf3e3a0e1f3f1b3f6f5e4d3c2b1a"
+	)
+	cl, srv := newFakeClient(t, Opts{})
+
+	subA, err := cl.NewAddressSubscription(context.Background(), addrA)
+	require.NoError(t, err)
+	subB, err := cl.NewAddressSubscription(context.Background(), addrB)
+	require.NoError(t, err)
+	subTx, err := cl.NewTransactionSubscription(context.Background(), txHash)
+	require.NoError(t, err)
+	// acknowledgements are consumed by the subscribe calls
+	require.Len(t, cl.Unmatched(), 0)
+
+	for _, tx := range []map[string]interface{}{
+		{"hash": "0x01", "watchedAddress": "0xdac17f958d2ee523a2206206994597c13d831ec7"},
+		{"hash": txHash},
+		{"hash": "0x02", "watchedAddress": "0xfa6de2697d59e88ed7fc4dfe5a33dac43565ea41"},
+		{"hash": "0x03", "watchedAddress": "0x0000000000000000000000000000000000000001"},
+	} {
+		require.NoError(t, srv.SendTx(blocknativetest.TxEvent{Transaction: tx}))
+	}
+
+	next := func(sub *Subscription[EthTxPayload]) EthTxPayload {
+		select {
+		case ev := <-sub.Events():
+			return ev
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for event")
+		}
+		return EthTxPayload{}
+	}
+	require.Equal(t, "0x01", next(subB).Event.Transaction.Hash)
+	require.Equal(t, txHash, next(subTx).Event.Transaction.Hash)
+	require.Equal(t, "0x02", next(subA).Event.Transaction.Hash)
+
+	var out EthTxPayload
+	require.NoError(t, cl.ReadJSON(&out))
+	require.Equal(t, "0x03", out.Event.Transaction.Hash)
+
+	for _, sub := range []*Subscription[EthTxPayload]{subA, subB, subTx} {
+		require.Len(t, sub.Events(), 0)
+	}
+}
@@ -0,0 +1,87 @@
+package client
+
+import (
+	"errors"
+	"strings"
+)
+
+// Errors reported by blocknative. A ServerError wraps the matching sentinel
+// so callers can test for them with errors.Is
+var (
+	ErrInvalidAPIKey       = errors.New("invalid api key")
+	ErrRateLimited         = errors.New("rate limited")
+	ErrInvalidNetwork      = errors.New("network not supported")
+	ErrMessageTooLarge     = errors.New("message too large")
+	ErrUnknownSubscription = errors.New("unknown subscription")
+	ErrSubscriptionLimit   = errors.New("maximum number of watched entities reached")
+	ErrInvalidAddress      = errors.New("invalid address")
+	ErrInvalidTxHash       = errors.New("invalid transaction hash")
+	ErrInvalidFilter       = errors.New("invalid filter")
+)
+
+// serverErrorReasons maps fragments of the reasons blocknative sends
+// (matched case insensitively) to the sentinel errors above
+var serverErrorReasons = []struct {
+	fragment string
+	err      error
+}{
+	{"not a valid api key", ErrInvalidAPIKey},
+	{"ratelimit", ErrRateLimited},
+	{"rate limit", ErrRateLimited},
+	{"network not supported", ErrInvalidNetwork},
+	{"message too large", ErrMessageTooLarge},
+	{"maximum allowed amount", ErrSubscriptionLimit},
+	{"invalid txid", ErrInvalidTxHash},
+	{"not a valid transaction hash", ErrInvalidTxHash},
+	{"address is not a valid", ErrInvalidAddress},
+	{"not a valid ethereum address", ErrInvalidAddress},
+	{"not a valid bitcoin address", ErrInvalidAddress},
+	{"invalid filter", ErrInvalidFilter},
+	{"not subscribed", ErrUnknownSubscription},
+	{"not watching", ErrUnknownSubscription},
+	{"unknown subscription", ErrUnknownSubscription},
+}
+
+// ServerError is an error frame received from blocknative
+type ServerError struct {
+	// Reason is the explanation sent by blocknative
+	Reason string
+	// CategoryCode and EventCode identify the message which caused the error, if known
+	CategoryCode string
+	EventCode    EventCode
+	// Err is the sentinel matching Reason, nil if the reason is not recognized
+	Err error
+}
+
+// NewServerError returns a ServerError for reason, matching it against the known sentinel errors
+func NewServerError(reason string) *ServerError {
+	e := &ServerError{Reason: reason}
+	lower := strings.ToLower(reason)
+	for _, r := range serverErrorReasons {
+		if strings.Contains(lower, r.fragment) {
+			e.Err = r.err
+			break
+		}
+	}
+	return e
+}
+
+func (e *ServerError) Error() string {
+	if e.Reason == "" {
+		return "blocknative error"
+	}
+	return "blocknative error: " + e.Reason
+}
+
+// Unwrap returns the matching sentinel error
+func (e *ServerError) Unwrap() error {
+	return e.Err
+}
+
+// serverError converts an error frame to a ServerError
+func serverError(hdr *frameHeader) *ServerError {
+	e := NewServerError(hdr.Reason)
+	e.CategoryCode = hdr.Event.CategoryCode
+	e.EventCode = hdr.Event.EventCode
+	return e
+}
@@ -0,0 +1,65 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerErrorReasons(t *testing.T) {
+	for reason, want := range map[string]error{
+		"xxxx is not a valid API key":                          ErrInvalidAPIKey,
+		"ratelimit exceeded, try again later":                  ErrRateLimited,
+		"blockchain network not supported":                     ErrInvalidNetwork,
+		"Message too large, limit is 200kb":                    ErrMessageTooLarge,
+		"Address is not a valid Ethereum address":              ErrInvalidAddress,
+		"invalid txid 0x01":                                    ErrInvalidTxHash,
+		"Invalid filter: unknown operator":                     ErrInvalidFilter,
+		"exceeded the maximum allowed amount of watched items": ErrSubscriptionLimit,
+		"address is not subscribed":                            ErrUnknownSubscription,
+	} {
+		err := NewServerError(reason)
+		require.True(t, errors.Is(err, want), reason)
+		require.Contains(t, err.Error(), reason)
+	}
+	err := NewServerError("something new")
+	require.Nil(t, err.Unwrap())
+}
+
+func TestErrorFrames(t *testing.T) {
+	const address = "0xfa6de2697d59e88ed7fc4dfe5a33dac43565ea41"
+	cl, srv := newFakeClient(t, Opts{})
+	srv.Reject("configs", "", "Invalid filter")
+	srv.Reject("accountAddress", address, "address is not a valid Ethereum address")
+
+	_, err := cl.NewEventSubscription(context.Background(), NewConfiguration(NewBaseMessageMainnet("key"), NewConfig("global", false, nil)))
+	require.ErrorIs(t, err, ErrInvalidFilter)
+	var srvErr *ServerError
+	require.True(t, errors.As(err, &srvErr))
+	require.Equal(t, "configs", srvErr.CategoryCode)
+
+	// subscribe errors are returned synchronously
+	_, err = cl.NewAddressSubscription(context.Background(), address)
+	require.ErrorIs(t, err, ErrInvalidAddress)
+	require.False(t, cl.HasSubscription(address))
+
+	// errors not tied to a subscription are broadcast
+	sub, err := cl.NewTransactionSubscription(context.Background(), "0x01")
+	require.NoError(t, err)
+	require.NoError(t, srv.SendError("ratelimit exceeded", nil))
+	select {
+	case err := <-sub.Err():
+		require.ErrorIs(t, err, ErrRateLimited)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for error")
+	}
+
+	// as are unsubscribe errors
+	srv.Reject("activeTransaction", "0x01", "not subscribed to 0x01")
+	require.ErrorIs(t, sub.Unsubscribe(), ErrUnknownSubscription)
+	_, ok := <-sub.Events()
+	require.False(t, ok)
+}
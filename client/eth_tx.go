@@ -2,24 +2,89 @@ package client
 
 import (
 	"fmt"
-	"strconv"
+	"math/big"
 
-	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/core/types"
 )
 
-func ParseGas(msg *EthTxPayload) (gasBaseFeeGwei, gasTipGwei float64, err error) {
-	gasBaseFee, err := strconv.ParseFloat(msg.Event.Transaction.MaxFeePerGas, 64)
-	if err != nil {
-		return 0, 0, fmt.Errorf("parsing gas base fee: %v, err=%v", msg.Event.Transaction.MaxFeePerGas, err)
+// GasInfo is the gas pricing of a transaction, amounts are in wei
+type GasInfo struct {
+	// Type is the transaction type
+	Type int
+	// GasFeeCap is the most the sender pays per gas and GasTipCap the most
+	// of it which goes to the miner. Both are the gas price for legacy and
+	// access list transactions, as in go-ethereum
+	GasFeeCap *big.Int
+	GasTipCap *big.Int
+	// BaseFee is the base fee per gas reported with the transaction, nil if
+	// the payload has none
+	BaseFee *big.Int
+	// EffectiveGasPrice is what the transaction pays per gas at BaseFee and
+	// EffectiveTip the part of it which goes to the miner, following
+	// EIP-1559. Both are nil if BaseFee is nil or above GasFeeCap, as the
+	// transaction can't be included then
+	EffectiveGasPrice *big.Int
+	EffectiveTip      *big.Int
+}
+
+// ParseGasInfo returns the gas pricing of the transaction in msg. Dynamic
+// fee transactions are priced by maxFeePerGas and maxPriorityFeePerGas,
+// other transactions by gasPrice
+func ParseGasInfo(msg *EthTxPayload) (GasInfo, error) {
+	t := &msg.Event.Transaction
+	g := GasInfo{Type: t.Type}
+	if t.Type >= types.DynamicFeeTxType || (!t.GasPrice.IsSet() && t.MaxFeePerGas.IsSet()) {
+		if !t.MaxFeePerGas.IsSet() {
+			return GasInfo{}, fmt.Errorf("parsing gas of type %d transaction: maxFeePerGas not set", t.Type)
+		}
+		if !t.MaxPriorityFeePerGas.IsSet() {
+			return GasInfo{}, fmt.Errorf("parsing gas of type %d transaction: maxPriorityFeePerGas not set", t.Type)
+		}
+		g.GasFeeCap, g.GasTipCap = t.MaxFeePerGas.Int(), t.MaxPriorityFeePerGas.Int()
+	} else {
+		if !t.GasPrice.IsSet() {
+			return GasInfo{}, fmt.Errorf("parsing gas of type %d transaction: gasPrice not set", t.Type)
+		}
+		g.GasFeeCap, g.GasTipCap = t.GasPrice.Int(), t.GasPrice.Int()
+	}
+	if t.BaseFeePerGas.IsSet() {
+		return g.WithBaseFee(t.BaseFeePerGas.Int()), nil
+	}
+	return g, nil
+}
+
+// WithBaseFee returns g priced at baseFee, such as the expected base fee of
+// the next block. A nil baseFee clears it
+func (g GasInfo) WithBaseFee(baseFee *big.Int) GasInfo {
+	g.BaseFee, g.EffectiveGasPrice, g.EffectiveTip = nil, nil, nil
+	if baseFee == nil {
+		return g
+	}
+	g.BaseFee = new(big.Int).Set(baseFee)
+	headroom := new(big.Int).Sub(g.GasFeeCap, baseFee)
+	if headroom.Sign() < 0 {
+		return g
+	}
+	g.EffectiveTip = new(big.Int).Set(g.GasTipCap)
+	if headroom.Cmp(g.GasTipCap) < 0 {
+		g.EffectiveTip = headroom
 	}
+	g.EffectiveGasPrice = new(big.Int).Add(baseFee, g.EffectiveTip)
+	return g
+}
 
-	gasBaseFeeGwei = gasBaseFee / params.GWei
+// Includable reports whether the transaction pays at least its base fee,
+// false if the base fee is unknown
+func (g GasInfo) Includable() bool {
+	return g.EffectiveGasPrice != nil
+}
 
-	gasTip, err := strconv.ParseFloat(msg.Event.Transaction.MaxPriorityFeePerGas, 64)
+// ParseGas returns the most the transaction in msg pays per gas and the
+// most of it which goes to the miner, in gwei. See ParseGasInfo
+func ParseGas(msg *EthTxPayload) (maxFeeGwei, tipGwei *big.Rat, err error) {
+	g, err := ParseGasInfo(msg)
 	if err != nil {
-		return 0, 0, fmt.Errorf("parsing max priority fee: %v, err=%v", msg.Event.Transaction.MaxPriorityFeePerGas, err)
+		return nil, nil, err
 	}
-	gasTipGwei = gasTip / params.GWei
-
-	return gasBaseFeeGwei, gasTipGwei, nil
+	return ToGwei(g.GasFeeCap), ToGwei(g.GasTipCap), nil
 }
@@ -0,0 +1,103 @@
+package client
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseGasInfo(t *testing.T) {
+	for _, tc := range []struct {
+		name                  string
+		tx                    string
+		feeCap, tipCap        int64
+		effectivePrice, tip   int64
+		includable, baseFeeOk bool
+	}{
+		{
+			name:   "legacy",
+			tx:     `{"type":0,"gasPrice":"30000000000","baseFeePerGas":"25000000000"}`,
+			feeCap: 30e9, tipCap: 30e9, effectivePrice: 30e9, tip: 5e9, includable: true, baseFeeOk: true,
+		},
+		{
+			name:   "access list",
+			tx:     `{"type":1,"gasPrice":"0x6fc23ac00","maxFeePerGas":"","baseFeePerGas":"25000000000"}`,
+			feeCap: 30e9, tipCap: 30e9, effectivePrice: 30e9, tip: 5e9, includable: true, baseFeeOk: true,
+		},
+		{
+			name:   "dynamic fee capped by tip",
+			tx:     `{"type":2,"maxFeePerGas":"40000000000","maxPriorityFeePerGas":"2000000000","baseFeePerGas":"25000000001"}`,
+			feeCap: 40e9, tipCap: 2e9, effectivePrice: 27000000001, tip: 2e9, includable: true, baseFeeOk: true,
+		},
+		{
+			name:   "dynamic fee capped by fee cap",
+			tx:     `{"type":2,"maxFeePerGas":"26000000000","maxPriorityFeePerGas":"2000000000","baseFeePerGas":"25000000001"}`,
+			feeCap: 26e9, tipCap: 2e9, effectivePrice: 26e9, tip: 999999999, includable: true, baseFeeOk: true,
+		},
+		{
+			name:   "dynamic fee below base fee",
+			tx:     `{"type":2,"maxFeePerGas":"20000000000","maxPriorityFeePerGas":"2000000000","baseFeePerGas":"25000000000"}`,
+			feeCap: 20e9, tipCap: 2e9, baseFeeOk: true,
+		},
+		{
+			name:   "no base fee",
+			tx:     `{"type":2,"maxFeePerGas":"20000000000","maxPriorityFeePerGas":"2000000000"}`,
+			feeCap: 20e9, tipCap: 2e9,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var msg EthTxPayload
+			require.NoError(t, json.Unmarshal([]byte(`{"event":{"transaction":`+tc.tx+`}}`), &msg))
+			g, err := ParseGasInfo(&msg)
+			require.NoError(t, err)
+			require.Equal(t, big.NewInt(tc.feeCap), g.GasFeeCap)
+			require.Equal(t, big.NewInt(tc.tipCap), g.GasTipCap)
+			require.Equal(t, tc.baseFeeOk, g.BaseFee != nil)
+			require.Equal(t, tc.includable, g.Includable())
+			if tc.includable {
+				require.Equal(t, big.NewInt(tc.effectivePrice), g.EffectiveGasPrice)
+				require.Equal(t, big.NewInt(tc.tip), g.EffectiveTip)
+			} else {
+				require.Nil(t, g.EffectiveGasPrice)
+				require.Nil(t, g.EffectiveTip)
+			}
+		})
+	}
+}
+
+func TestParseGasInfoErrors(t *testing.T) {
+	for _, tx := range []string{
+		`{"type":0,"gasPrice":""}`,
+		`{"type":2,"maxFeePerGas":"1"}`,
+		`{"type":2,"maxPriorityFeePerGas":"1"}`,
+	} {
+		var msg EthTxPayload
+		require.NoError(t, json.Unmarshal([]byte(`{"event":{"transaction":`+tx+`}}`), &msg))
+		_, err := ParseGasInfo(&msg)
+		require.Error(t, err, tx)
+	}
+}
+
+func TestGasInfoWithBaseFee(t *testing.T) {
+	var msg EthTxPayload
+	require.NoError(t, json.Unmarshal([]byte(`{"event":{"transaction":{"type":2,"maxFeePerGas":"30000000000","maxPriorityFeePerGas":"1500000000"}}}`), &msg))
+	g, err := ParseGasInfo(&msg)
+	require.NoError(t, err)
+	baseFee := big.NewInt(10e9)
+	next := g.WithBaseFee(baseFee)
+	baseFee.SetInt64(0)
+	require.Equal(t, big.NewInt(10e9), next.BaseFee)
+	require.Equal(t, big.NewInt(11500000000), next.EffectiveGasPrice)
+	require.Nil(t, g.BaseFee)
+	require.False(t, next.WithBaseFee(nil).Includable())
+
+	// legacy transactions are still priced by their gas price
+	msg.Event.Transaction.Type = 0
+	msg.Event.Transaction.GasPrice = NewBigInt(big.NewInt(12e9))
+	maxFee, tip, err := ParseGas(&msg)
+	require.NoError(t, err)
+	require.Equal(t, "12", maxFee.FloatString(0))
+	require.Equal(t, "12", tip.FloatString(0))
+}
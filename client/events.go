@@ -0,0 +1,99 @@
+package client
+
+// EventCode identifies a message sent to or an event emitted by blocknative.
+// Values blocknative adds in the future are preserved as is, use IsKnown
+// to tell them apart from the codes declared below
+type EventCode string
+
+// Event codes sent by the client
+const (
+	EventCheckDappID EventCode = "checkDappId"
+	EventWatch       EventCode = "watch"
+	EventUnwatch     EventCode = "unwatch"
+	EventPut         EventCode = "put"
+)
+
+// Transaction lifecycle event codes emitted by blocknative
+const (
+	EventTxSent           EventCode = "txSent"
+	EventTxPool           EventCode = "txPool"
+	EventTxPoolSimulation EventCode = "txPoolSimulation"
+	EventTxConfirmed      EventCode = "txConfirmed"
+	EventTxFailed         EventCode = "txFailed"
+	EventTxSpeedUp        EventCode = "txSpeedUp"
+	EventTxCancel         EventCode = "txCancel"
+	EventTxDropped        EventCode = "txDropped"
+	EventTxStuck          EventCode = "txStuck"
+)
+
+// Event codes blocknative reports for transactions sent through a wallet,
+// before they reach the mempool or while waiting for them to be mined
+const (
+	EventTxRequest         EventCode = "txRequest"
+	EventTxRejected        EventCode = "txRejected"
+	EventTxSendFail        EventCode = "txSendFail"
+	EventTxConfirmReminder EventCode = "txConfirmReminder"
+)
+
+var knownEventCodes = map[EventCode]bool{
+	EventCheckDappID:       true,
+	EventWatch:             true,
+	EventUnwatch:           true,
+	EventPut:               true,
+	EventTxSent:            true,
+	EventTxPool:            true,
+	EventTxPoolSimulation:  true,
+	EventTxConfirmed:       true,
+	EventTxFailed:          true,
+	EventTxSpeedUp:         true,
+	EventTxCancel:          true,
+	EventTxDropped:         true,
+	EventTxStuck:           true,
+	EventTxRequest:         true,
+	EventTxRejected:        true,
+	EventTxSendFail:        true,
+	EventTxConfirmReminder: true,
+}
+
+// String returns the code as sent over the wire
+func (e EventCode) String() string {
+	return string(e)
+}
+
+// IsKnown reports whether e is one of the declared event codes
+func (e EventCode) IsKnown() bool {
+	return knownEventCodes[e]
+}
+
+// TxStatus is the status blocknative reports for a transaction.
+// Unknown statuses are preserved as is, see IsKnown
+type TxStatus string
+
+// Transaction statuses reported by blocknative
+const (
+	StatusPending   TxStatus = "pending"
+	StatusConfirmed TxStatus = "confirmed"
+	StatusSpeedUp   TxStatus = "speedup"
+	StatusCancel    TxStatus = "cancel"
+	StatusFailed    TxStatus = "failed"
+	StatusDropped   TxStatus = "dropped"
+)
+
+var knownStatuses = map[TxStatus]bool{
+	StatusPending:   true,
+	StatusConfirmed: true,
+	StatusSpeedUp:   true,
+	StatusCancel:    true,
+	StatusFailed:    true,
+	StatusDropped:   true,
+}
+
+// String returns the status as sent over the wire
+func (s TxStatus) String() string {
+	return string(s)
+}
+
+// IsKnown reports whether s is one of the declared statuses
+func (s TxStatus) IsKnown() bool {
+	return knownStatuses[s]
+}
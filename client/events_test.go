@@ -0,0 +1,34 @@
+package client
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventCodes(t *testing.T) {
+	payload := []byte(`{"event":{"eventCode":"txSpeedUp","transaction":{"status":"speedup"}}}`)
+	var ev EthTxPayload
+	require.NoError(t, json.Unmarshal(payload, &ev))
+	require.Equal(t, EventTxSpeedUp, ev.Event.EventCode)
+	require.Equal(t, StatusSpeedUp, ev.Event.Transaction.Status)
+	require.True(t, ev.Event.EventCode.IsKnown())
+	require.True(t, ev.Event.Transaction.Status.IsKnown())
+	for _, code := range []string{"txRequest", "txRejected", "txSendFail", "txConfirmReminder"} {
+		require.True(t, EventCode(code).IsKnown(), code)
+	}
+
+	// codes we don't know about survive a round trip
+	payload = []byte(`{"event":{"eventCode":"txRebroadcast","transaction":{"status":"simulated"}}}`)
+	require.NoError(t, json.Unmarshal(payload, &ev))
+	require.False(t, ev.Event.EventCode.IsKnown())
+	require.False(t, ev.Event.Transaction.Status.IsKnown())
+	require.Equal(t, "txRebroadcast", ev.Event.EventCode.String())
+	out, err := json.Marshal(ev)
+	require.NoError(t, err)
+	var back EthTxPayload
+	require.NoError(t, json.Unmarshal(out, &back))
+	require.Equal(t, EventCode("txRebroadcast"), back.Event.EventCode)
+	require.Equal(t, TxStatus("simulated"), back.Event.Transaction.Status)
+}
@@ -0,0 +1,388 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// FilterBuilder builds a filter for Config.Filters in blocknative's jsql
+// filter language (https://github.com/deitch/searchjs). Every condition of
+// a filter must match, use Or to match any of several filters:
+//
+//	f := Or(
+//		Filter().Field("value").Gte(big.NewInt(1e18)),
+//		Filter().Field("contractCall.methodName").In("transfer", "transferFrom").PropertySearch(),
+//	)
+//
+// Errors made while building are reported by Build
+type FilterBuilder struct {
+	fields         map[string]interface{}
+	join           string
+	terms          []*FilterBuilder
+	not            bool
+	propertySearch bool
+	err            error
+}
+
+// Filter returns an empty filter
+func Filter() *FilterBuilder {
+	return &FilterBuilder{fields: make(map[string]interface{})}
+}
+
+// Or returns a filter matching when any of filters matches
+func Or(filters ...*FilterBuilder) *FilterBuilder {
+	return &FilterBuilder{join: "OR", terms: filters}
+}
+
+// And returns a filter matching when all of filters match
+func And(filters ...*FilterBuilder) *FilterBuilder {
+	return &FilterBuilder{join: "AND", terms: filters}
+}
+
+// FieldCondition adds a condition on a single field to a filter
+type FieldCondition struct {
+	f    *FilterBuilder
+	name string
+}
+
+// Field starts a condition on the transaction field name, nested fields
+// are separated by dots as in "contractCall.params._value"
+func (f *FilterBuilder) Field(name string) FieldCondition {
+	return FieldCondition{f: f, name: name}
+}
+
+// Eq matches when the field equals v
+func (c FieldCondition) Eq(v interface{}) *FilterBuilder {
+	return c.f.set(c.name, "", v)
+}
+
+// In matches when the field equals any of values
+func (c FieldCondition) In(values ...interface{}) *FilterBuilder {
+	if len(values) == 0 {
+		return c.f.fail(fmt.Errorf("field %q: In needs at least one value", c.name))
+	}
+	return c.f.set(c.name, "", values)
+}
+
+// Gt matches when the field is greater than v
+func (c FieldCondition) Gt(v interface{}) *FilterBuilder {
+	return c.f.set(c.name, "gt", v)
+}
+
+// Gte matches when the field is greater than or equal to v
+func (c FieldCondition) Gte(v interface{}) *FilterBuilder {
+	return c.f.set(c.name, "gte", v)
+}
+
+// Lt matches when the field is less than v
+func (c FieldCondition) Lt(v interface{}) *FilterBuilder {
+	return c.f.set(c.name, "lt", v)
+}
+
+// Lte matches when the field is less than or equal to v
+func (c FieldCondition) Lte(v interface{}) *FilterBuilder {
+	return c.f.set(c.name, "lte", v)
+}
+
+// Not inverts the filter
+func (f *FilterBuilder) Not() *FilterBuilder {
+	f.not = true
+	return f
+}
+
+// PropertySearch makes the filter match fields at any depth of the
+// transaction, rather than only at the given path
+func (f *FilterBuilder) PropertySearch() *FilterBuilder {
+	f.propertySearch = true
+	return f
+}
+
+func (f *FilterBuilder) fail(err error) *FilterBuilder {
+	if f.err == nil {
+		f.err = err
+	}
+	return f
+}
+
+// set adds a condition on field name, op is empty for equality
+func (f *FilterBuilder) set(name, op string, v interface{}) *FilterBuilder {
+	if f.err != nil {
+		return f
+	}
+	if f.join != "" {
+		return f.fail(fmt.Errorf("field %q: conditions can't be added to an %s filter", name, f.join))
+	}
+	if name == "" || strings.HasPrefix(name, "_") {
+		return f.fail(fmt.Errorf("invalid field name %q", name))
+	}
+	if values, ok := v.([]interface{}); ok && op == "" {
+		list := make([]interface{}, len(values))
+		for i, v := range values {
+			val, err := filterValue(v)
+			if err != nil {
+				return f.fail(fmt.Errorf("field %q: %w", name, err))
+			}
+			list[i] = val
+		}
+		v = list
+	} else {
+		val, err := filterValue(v)
+		if err != nil {
+			return f.fail(fmt.Errorf("field %q: %w", name, err))
+		}
+		if _, isBool := val.(bool); isBool && op != "" {
+			return f.fail(fmt.Errorf("field %q: %s needs a number or string", name, op))
+		}
+		v = val
+	}
+	prev, exists := f.fields[name]
+	if op == "" {
+		if exists {
+			return f.fail(fmt.Errorf("field %q already has a condition", name))
+		}
+		f.fields[name] = v
+		return f
+	}
+	ops, isRange := prev.(map[string]interface{})
+	switch {
+	case !exists:
+		ops = make(map[string]interface{})
+		f.fields[name] = ops
+	case !isRange:
+		return f.fail(fmt.Errorf("field %q already has a condition", name))
+	}
+	if _, ok := ops[op]; ok {
+		return f.fail(fmt.Errorf("field %q already has a %s condition", name, op))
+	}
+	ops[op] = v
+	return f
+}
+
+// Build returns the filter as it is sent in Config.Filters
+func (f *FilterBuilder) Build() (map[string]interface{}, error) {
+	out, err := f.build()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFilter, err)
+	}
+	return out, nil
+}
+
+func (f *FilterBuilder) build() (map[string]interface{}, error) {
+	if f == nil {
+		return nil, fmt.Errorf("nil filter")
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+	out := make(map[string]interface{})
+	if f.join != "" {
+		if len(f.terms) == 0 {
+			return nil, fmt.Errorf("%s needs at least one filter", f.join)
+		}
+		terms := make([]interface{}, len(f.terms))
+		for i, t := range f.terms {
+			term, err := t.build()
+			if err != nil {
+				return nil, err
+			}
+			terms[i] = term
+		}
+		out["_join"] = f.join
+		out["terms"] = terms
+	} else {
+		if len(f.fields) == 0 {
+			return nil, fmt.Errorf("filter has no conditions")
+		}
+		for name, v := range f.fields {
+			if ops, ok := v.(map[string]interface{}); ok {
+				// copy so later changes to the builder don't alter built filters
+				cp := make(map[string]interface{}, len(ops))
+				for op, v := range ops {
+					cp[op] = v
+				}
+				v = cp
+			}
+			out[name] = v
+		}
+	}
+	if f.not {
+		out["_not"] = true
+	}
+	if f.propertySearch {
+		out["_propertySearch"] = true
+	}
+	return out, nil
+}
+
+// MarshalJSON encodes the built filter
+func (f *FilterBuilder) MarshalJSON() ([]byte, error) {
+	out, err := f.Build()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(out)
+}
+
+// AddFilter builds f and appends it to the filters of c
+func (c *Config) AddFilter(f *FilterBuilder) error {
+	out, err := f.Build()
+	if err != nil {
+		return err
+	}
+	c.Filters = append(c.Filters, out)
+	return nil
+}
+
+// filterValue converts v to a value jsql can compare transaction fields with.
+// Big integers become decimal strings, as blocknative reports wei amounts,
+// and a JSON number above 2^53 would lose precision on the way
+func filterValue(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case string, bool, json.Number,
+		int, int8, int16, int32, int64,
+		uint, uint8, uint16, uint32, uint64:
+		return v, nil
+	case float32:
+		return filterValue(float64(v))
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, fmt.Errorf("invalid number %v", v)
+		}
+		return v, nil
+	case *big.Int:
+		if v == nil {
+			return nil, fmt.Errorf("nil number")
+		}
+		return v.String(), nil
+	case big.Int:
+		return v.String(), nil
+	case BigInt:
+		return v.Int().String(), nil
+	case common.Address:
+		// blocknative reports addresses in lower case
+		return strings.ToLower(v.Hex()), nil
+	case common.Hash:
+		return v.Hex(), nil
+	}
+	return nil, fmt.Errorf("unsupported value %v of type %T", v, v)
+}
+
+// jsql modifiers which may appear as keys of a filter
+var filterModifiers = map[string]bool{
+	"_not":            true,
+	"_propertySearch": true,
+	"_text":           true,
+	"_word":           true,
+	"_start":          true,
+	"_end":            true,
+}
+
+// jsql range operators
+var filterOps = map[string]bool{"gt": true, "gte": true, "lt": true, "lte": true, "from": true, "to": true}
+
+// ValidateFilters checks filters, such as Config.Filters, for mistakes
+// blocknative would reject. The returned error wraps ErrInvalidFilter
+func ValidateFilters(filters []map[string]interface{}) error {
+	for i, f := range filters {
+		if err := validateFilter(f); err != nil {
+			return fmt.Errorf("%w: filter %d: %v", ErrInvalidFilter, i, err)
+		}
+	}
+	return nil
+}
+
+func validateFilter(f map[string]interface{}) error {
+	if len(f) == 0 {
+		return fmt.Errorf("filter has no conditions")
+	}
+	if join, ok := f["_join"]; ok {
+		if join != "OR" && join != "AND" {
+			return fmt.Errorf("invalid _join %v", join)
+		}
+		var terms []map[string]interface{}
+		switch t := f["terms"].(type) {
+		case []map[string]interface{}:
+			terms = t
+		case []interface{}:
+			for _, term := range t {
+				m, ok := term.(map[string]interface{})
+				if !ok {
+					return fmt.Errorf("invalid term %v", term)
+				}
+				terms = append(terms, m)
+			}
+		default:
+			return fmt.Errorf("_join needs terms")
+		}
+		if len(terms) == 0 {
+			return fmt.Errorf("_join needs terms")
+		}
+		for _, term := range terms {
+			if err := validateFilter(term); err != nil {
+				return err
+			}
+		}
+	}
+	for name, v := range f {
+		switch {
+		case name == "_join" || name == "terms" && f["_join"] != nil:
+		case strings.HasPrefix(name, "_"):
+			if !filterModifiers[name] {
+				return fmt.Errorf("unknown modifier %q", name)
+			}
+			if s, ok := v.(string); ok && (s == "true" || s == "false") {
+				continue
+			}
+			if _, ok := v.(bool); !ok {
+				return fmt.Errorf("modifier %q needs a boolean", name)
+			}
+		case name == "":
+			return fmt.Errorf("empty field name")
+		default:
+			if err := validateCondition(v); err != nil {
+				return fmt.Errorf("field %q: %v", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func validateCondition(v interface{}) error {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 {
+			return fmt.Errorf("empty condition")
+		}
+		for op, val := range v {
+			if !filterOps[op] {
+				return fmt.Errorf("unknown operator %q", op)
+			}
+			if _, err := filterValue(val); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			return fmt.Errorf("empty list")
+		}
+		for _, val := range v {
+			if _, err := filterValue(val); err != nil {
+				return err
+			}
+		}
+	case []string:
+		if len(v) == 0 {
+			return fmt.Errorf("empty list")
+		}
+	default:
+		if _, err := filterValue(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
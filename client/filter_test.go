@@ -0,0 +1,92 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterBuilder(t *testing.T) {
+	wei, _ := new(big.Int).SetString("1000000000000000000000", 10)
+	f := Or(
+		Filter().Field("value").Gte(wei).Field("value").Lt(wei).Field("from").Eq(common.HexToAddress("0xFA6DE2697D59E88ED7FC4DFE5A33DAC43565EA41")),
+		Filter().Field("contractCall.methodName").In("transfer", "transferFrom").PropertySearch(),
+		And(Filter().Field("gas").Gt(21000), Filter().Field("status").Eq("failed").Not()),
+	)
+	data, err := json.Marshal(f)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"_join": "OR",
+		"terms": [
+			{"value": {"gte": "1000000000000000000000", "lt": "1000000000000000000000"}, "from": "0xfa6de2697d59e88ed7fc4dfe5a33dac43565ea41"},
+			{"contractCall.methodName": ["transfer", "transferFrom"], "_propertySearch": true},
+			{"_join": "AND", "terms": [{"gas": {"gt": 21000}}, {"status": "failed", "_not": true}]}
+		]
+	}`, string(data))
+
+	var cfg Config
+	require.NoError(t, cfg.AddFilter(f))
+	require.NoError(t, ValidateFilters(cfg.Filters))
+
+	for name, f := range map[string]*FilterBuilder{
+		"empty":           Filter(),
+		"empty or":        Or(),
+		"reserved field":  Filter().Field("_not").Eq(true),
+		"duplicate":       Filter().Field("to").Eq("a").Field("to").Eq("b"),
+		"range after eq":  Filter().Field("gas").Eq(1).Field("gas").Gt(2),
+		"duplicate range": Filter().Field("gas").Gt(1).Field("gas").Gt(2),
+		"bool range":      Filter().Field("gas").Gt(true),
+		"empty in":        Filter().Field("to").In(),
+		"unsupported":     Filter().Field("to").Eq(struct{}{}),
+		"nil number":      Filter().Field("value").Gte((*big.Int)(nil)),
+		"field on or":     Or(Filter().Field("to").Eq("a")).Field("from").Eq("b"),
+		"invalid term":    Or(Filter().Field("to").Eq("a"), Filter()),
+	} {
+		_, err := f.Build()
+		require.ErrorIs(t, err, ErrInvalidFilter, name)
+	}
+}
+
+func TestFilterBigInt(t *testing.T) {
+	// 2^53+1 can't be represented by a float64
+	n, _ := new(big.Int).SetString("9007199254740993", 10)
+	for _, v := range []interface{}{n, *n, NewBigInt(n)} {
+		out, err := Filter().Field("value").Gt(v).Build()
+		require.NoError(t, err)
+		data, err := json.Marshal(out)
+		require.NoError(t, err)
+		var decoded map[string]map[string]interface{}
+		require.NoError(t, json.Unmarshal(data, &decoded))
+		require.Equal(t, "9007199254740993", decoded["value"]["gt"], "%T", v)
+	}
+}
+
+func TestValidateFilters(t *testing.T) {
+	require.NoError(t, ValidateFilters([]map[string]interface{}{
+		{"contractCall.methodName": "transfer", "_propertySearch": "true"},
+		{"_join": "OR", "terms": []interface{}{map[string]interface{}{"value": map[string]interface{}{"from": 1, "to": 2}}}},
+	}))
+	for name, f := range map[string]map[string]interface{}{
+		"empty":            {},
+		"unknown modifier": {"_nope": true, "to": "a"},
+		"unknown operator": {"value": map[string]interface{}{"$gte": 1}},
+		"bad join":         {"_join": "XOR", "terms": []interface{}{map[string]interface{}{"to": "a"}}},
+		"no terms":         {"_join": "OR"},
+		"unsupported":      {"to": struct{}{}},
+	} {
+		require.ErrorIs(t, ValidateFilters([]map[string]interface{}{f}), ErrInvalidFilter, name)
+	}
+
+	// invalid filters are rejected before anything is sent
+	cl, srv := newFakeClient(t, Opts{})
+	sent := len(srv.Received())
+	cfg := NewConfig("global", false, nil)
+	cfg.Filters = []map[string]interface{}{{"value": map[string]interface{}{"$gte": 1}}}
+	_, err := cl.NewEventSubscription(context.Background(), NewConfiguration(NewBaseMessageMainnet("test-key"), cfg))
+	require.ErrorIs(t, err, ErrInvalidFilter)
+	require.Len(t, srv.Received(), sent)
+}
@@ -0,0 +1,158 @@
+package client
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ethAddress parses an ethereum address, the returned error wraps ErrInvalidAddress
+func ethAddress(field, s string) (common.Address, error) {
+	if err := ValidateAddress(Blockchain{System: SystemEthereum}, s); err != nil {
+		return common.Address{}, fmt.Errorf("%s: %w", field, err)
+	}
+	return common.HexToAddress(s), nil
+}
+
+// From returns the sender of the transaction
+func (p *EthTxPayload) From() (common.Address, error) {
+	return ethAddress("from", p.Event.Transaction.From)
+}
+
+// To returns the recipient of the transaction, nil for contract creations
+func (p *EthTxPayload) To() (*common.Address, error) {
+	if p.Event.Transaction.To == "" {
+		return nil, nil
+	}
+	to, err := ethAddress("to", p.Event.Transaction.To)
+	if err != nil {
+		return nil, err
+	}
+	return &to, nil
+}
+
+// WatchedAddress returns the address whose subscription the event was sent for
+func (p *EthTxPayload) WatchedAddress() (common.Address, error) {
+	return ethAddress("watchedAddress", p.Event.Transaction.WatchedAddress)
+}
+
+// TxHash returns the hash of the transaction, the returned error wraps
+// ErrInvalidTxHash if it isn't 32 bytes of 0x prefixed hex
+func (p *EthTxPayload) TxHash() (common.Hash, error) {
+	h := p.Event.Transaction.Hash
+	b, err := hexutil.Decode(h)
+	if err != nil || len(b) != common.HashLength {
+		return common.Hash{}, fmt.Errorf("hash %q: %w", h, ErrInvalidTxHash)
+	}
+	return common.BytesToHash(b), nil
+}
+
+// ChainID returns the chain id of the network the event was sent for, see NetworkByName
+func (p *EthTxPayload) ChainID() (*big.Int, error) {
+	n, err := NetworkByName(p.Event.System, p.Event.Network)
+	if err != nil {
+		return nil, err
+	}
+	return big.NewInt(n.ChainID), nil
+}
+
+// ToTransaction builds the go-ethereum transaction described by the
+// payload: a legacy, access list or dynamic fee transaction depending on
+// Transaction.Type. When the payload carries a signature the transaction is
+// signed with it, and its hash must match the payload's
+func (p *EthTxPayload) ToTransaction() (*types.Transaction, error) {
+	t := &p.Event.Transaction
+	input, err := hexutil.Decode(orEmptyHex(t.Input))
+	if err != nil {
+		return nil, fmt.Errorf("input: %w", err)
+	}
+	to, err := p.To()
+	if err != nil {
+		return nil, err
+	}
+	v, r, s := t.V.Int(), t.R.Int(), t.S.Int()
+	var inner types.TxData
+	switch t.Type {
+	case types.LegacyTxType:
+		inner = &types.LegacyTx{
+			Nonce: t.Nonce.Uint64(), GasPrice: t.GasPrice.Int(), Gas: t.Gas.Uint64(),
+			To: to, Value: t.Value.Int(), Data: input, V: v, R: r, S: s,
+		}
+	case types.AccessListTxType, types.DynamicFeeTxType:
+		chainID, err := p.ChainID()
+		if err != nil {
+			return nil, err
+		}
+		if t.Type == types.AccessListTxType {
+			inner = &types.AccessListTx{
+				ChainID: chainID, Nonce: t.Nonce.Uint64(), GasPrice: t.GasPrice.Int(), Gas: t.Gas.Uint64(),
+				To: to, Value: t.Value.Int(), Data: input, AccessList: t.AccessList, V: v, R: r, S: s,
+			}
+		} else {
+			inner = &types.DynamicFeeTx{
+				ChainID: chainID, Nonce: t.Nonce.Uint64(), GasTipCap: t.MaxPriorityFeePerGas.Int(), GasFeeCap: t.MaxFeePerGas.Int(),
+				Gas: t.Gas.Uint64(), To: to, Value: t.Value.Int(), Data: input, AccessList: t.AccessList, V: v, R: r, S: s,
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported transaction type %d", t.Type)
+	}
+	tx := types.NewTx(inner)
+	if t.R.IsSet() && t.S.IsSet() && t.Hash != "" {
+		hash, err := p.TxHash()
+		if err != nil {
+			return nil, err
+		}
+		if tx.Hash() != hash {
+			return nil, fmt.Errorf("built transaction has hash %s, payload has %s", tx.Hash(), hash)
+		}
+	}
+	return tx, nil
+}
+
+// NewEthTxPayload describes tx as blocknative would on the network with
+// the transaction's chain id, or on chain if tx is unsigned or not protected
+// by a chain id. from is the sender, the zero address leaves it unset
+func NewEthTxPayload(tx *types.Transaction, from common.Address, chain Blockchain) EthTxPayload {
+	var p EthTxPayload
+	p.Event.Blockchain = chain
+	if id := tx.ChainId(); id.Sign() > 0 && id.IsInt64() {
+		if n, err := NetworkByChainID(id.Int64()); err == nil {
+			p.Event.Blockchain = n.Blockchain()
+		}
+	}
+	t := &p.Event.Transaction
+	t.Type = int(tx.Type())
+	t.Hash = tx.Hash().Hex()
+	if from != (common.Address{}) {
+		t.From = from.Hex()
+	}
+	if to := tx.To(); to != nil {
+		t.To = to.Hex()
+	}
+	t.Value = NewBigInt(tx.Value())
+	t.Gas = NewBigInt(new(big.Int).SetUint64(tx.Gas()))
+	t.Nonce = NewBigInt(new(big.Int).SetUint64(tx.Nonce()))
+	t.Input = hexutil.Encode(tx.Data())
+	t.AccessList = tx.AccessList()
+	if tx.Type() == types.DynamicFeeTxType {
+		t.MaxFeePerGas = NewBigInt(tx.GasFeeCap())
+		t.MaxPriorityFeePerGas = NewBigInt(tx.GasTipCap())
+	} else {
+		t.GasPrice = NewBigInt(tx.GasPrice())
+	}
+	if v, r, s := tx.RawSignatureValues(); r != nil && r.Sign() != 0 {
+		t.V, t.R, t.S = NewBigInt(v), NewBigInt(r), NewBigInt(s)
+	}
+	return p
+}
+
+func orEmptyHex(s string) string {
+	if s == "" {
+		return "0x"
+	}
+	return s
+}
@@ -0,0 +1,94 @@
+package client
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEthTxPayloadTransaction(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	to := common.HexToAddress("0xfa6de2697d59e88ed7fc4dfe5a33dac43565ea41")
+	chainID := big.NewInt(137)
+	accessList := types.AccessList{{Address: to, StorageKeys: []common.Hash{common.HexToHash("0x01")}}}
+
+	for name, inner := range map[string]types.TxData{
+		"legacy":      &types.LegacyTx{Nonce: 1, GasPrice: big.NewInt(30e9), Gas: 21000, To: &to, Value: big.NewInt(1)},
+		"access list": &types.AccessListTx{ChainID: chainID, Nonce: 2, GasPrice: big.NewInt(30e9), Gas: 30000, To: &to, AccessList: accessList, Data: []byte{0xa9, 0x05}},
+		"dynamic fee": &types.DynamicFeeTx{ChainID: chainID, Nonce: 3, GasTipCap: big.NewInt(1e9), GasFeeCap: big.NewInt(40e9), Gas: 60000, AccessList: accessList, Data: []byte{0x60, 0x80}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			tx, err := types.SignNewTx(key, types.LatestSignerForChainID(chainID), inner)
+			require.NoError(t, err)
+			p := NewEthTxPayload(tx, from, Blockchain{})
+			require.Equal(t, "matic-main", p.Event.Network)
+
+			// the payload survives a round trip through json as blocknative sends it
+			data, err := json.Marshal(p)
+			require.NoError(t, err)
+			var got EthTxPayload
+			require.NoError(t, json.Unmarshal(data, &got))
+
+			built, err := got.ToTransaction()
+			require.NoError(t, err)
+			require.Equal(t, tx.Hash(), built.Hash())
+			sender, err := types.Sender(types.LatestSignerForChainID(chainID), built)
+			require.NoError(t, err)
+			require.Equal(t, from, sender)
+
+			gotFrom, err := got.From()
+			require.NoError(t, err)
+			require.Equal(t, from, gotFrom)
+			gotTo, err := got.To()
+			require.NoError(t, err)
+			require.Equal(t, tx.To(), gotTo)
+			hash, err := got.TxHash()
+			require.NoError(t, err)
+			require.Equal(t, tx.Hash(), hash)
+		})
+	}
+}
+
+func TestEthTxPayloadInvalid(t *testing.T) {
+	var p EthTxPayload
+	p.Event.Blockchain = Blockchain{System: "ethereum", Network: "main"}
+	p.Event.Transaction.From = "0xfa6de2697d59e88ed7fc4dfe5a33dac43565ea41"
+	p.Event.Transaction.To = "fa6de2697d59e88ed7fc4dfe5a33dac43565ea41"
+	p.Event.Transaction.WatchedAddress = "0x01"
+	p.Event.Transaction.Hash = "0x01"
+
+	from, err := p.From()
+	require.NoError(t, err)
+	require.Equal(t, "0xfa6de2697D59E88Ed7Fc4dFE5A33daC43565ea41", from.Hex())
+	_, err = p.To()
+	require.ErrorIs(t, err, ErrInvalidAddress)
+	_, err = p.WatchedAddress()
+	require.ErrorIs(t, err, ErrInvalidAddress)
+	_, err = p.TxHash()
+	require.ErrorIs(t, err, ErrInvalidTxHash)
+
+	// contract creations have no recipient
+	p.Event.Transaction.To = ""
+	to, err := p.To()
+	require.NoError(t, err)
+	require.Nil(t, to)
+
+	// a signed payload must hash to its hash
+	p.Event.Transaction.Type = 2
+	p.Event.Transaction.R = NewBigInt(big.NewInt(1))
+	p.Event.Transaction.S = NewBigInt(big.NewInt(1))
+	p.Event.Transaction.Hash = common.HexToHash("0x02").Hex()
+	_, err = p.ToTransaction()
+	require.Error(t, err)
+
+	p.Event.Transaction.Type = 4
+	_, err = p.ToTransaction()
+	require.Error(t, err)
+}
@@ -0,0 +1,88 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+var (
+	// DefaultPingInterval is used when Opts.PingInterval is zero
+	DefaultPingInterval = 30 * time.Second
+	// DefaultPongTimeout is used when Opts.PongTimeout is zero
+	DefaultPongTimeout = 10 * time.Second
+
+	// writeWait bounds how long writing a control frame may take
+	writeWait = 5 * time.Second
+)
+
+// ErrStaleConnection is reported to subscriptions when blocknative stops
+// answering pings and nothing else arrives on the connection
+var ErrStaleConnection = errors.New("websocket connection is stale")
+
+func (o Opts) pingInterval() time.Duration {
+	if o.PingInterval == 0 {
+		return DefaultPingInterval
+	}
+	return o.PingInterval
+}
+
+func (o Opts) pongTimeout() time.Duration {
+	if o.PongTimeout <= 0 {
+		return DefaultPongTimeout
+	}
+	return o.PongTimeout
+}
+
+// keepalive refreshes the read deadline of conn whenever a pong arrives
+func (c *Client) keepalive(conn *websocket.Conn) {
+	if c.opts.pingInterval() < 0 {
+		return
+	}
+	conn.SetPongHandler(func(string) error {
+		c.extendDeadline(conn)
+		return nil
+	})
+}
+
+// extendDeadline gives the server until the next ping has had time to be
+// answered to send us something
+func (c *Client) extendDeadline(conn *websocket.Conn) {
+	if interval := c.opts.pingInterval(); interval > 0 {
+		conn.SetReadDeadline(time.Now().Add(interval + c.opts.pongTimeout()))
+	}
+}
+
+// heartbeat pings blocknative until the read loop exits
+func (c *Client) heartbeat() {
+	interval := c.opts.pingInterval()
+	if interval < 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.wmtx.Lock()
+			conn := c.conn
+			c.wmtx.Unlock()
+			// a failed ping surfaces as a read error once the deadline passes
+			conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait))
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// staleErr wraps read deadline timeouts in ErrStaleConnection
+func staleErr(err error) error {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return fmt.Errorf("%w: %v", ErrStaleConnection, err)
+	}
+	return err
+}
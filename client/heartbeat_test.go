@@ -0,0 +1,43 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStaleConnection(t *testing.T) {
+	cl := newTestClient(t, Opts{PingInterval: 20 * time.Millisecond, PongTimeout: 20 * time.Millisecond}, func(conn *websocket.Conn) {
+		// a half-open connection never answers pings
+		conn.SetPingHandler(func(string) error { return nil })
+		acknowledge(conn)
+	})
+	sub, err := cl.NewAddressSubscription(context.Background(), "0xfa6de2697d59e88ed7fc4dfe5a33dac43565ea41")
+	require.NoError(t, err)
+	select {
+	case err := <-sub.Err():
+		require.True(t, errors.Is(err, ErrStaleConnection), err)
+	case <-time.After(time.Second):
+		t.Fatal("stale connection was not detected")
+	}
+	_, ok := <-sub.Events()
+	require.False(t, ok)
+}
+
+func TestHeartbeat(t *testing.T) {
+	// the pong timeout leaves room for a busy machine, pings keep the connection alive far longer
+	cl := newTestClient(t, Opts{PingInterval: 20 * time.Millisecond, PongTimeout: 250 * time.Millisecond}, func(conn *websocket.Conn) {
+		acknowledge(conn)
+	})
+	sub, err := cl.NewAddressSubscription(context.Background(), "0xfa6de2697d59e88ed7fc4dfe5a33dac43565ea41")
+	require.NoError(t, err)
+	select {
+	case err := <-sub.Err():
+		t.Fatalf("healthy connection reported %v", err)
+	case <-time.After(time.Second):
+	}
+}
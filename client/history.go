@@ -50,3 +50,25 @@ func (mg *MsgHistory) Len() int {
 	defer mg.mx.RUnlock()
 	return len(mg.buffer)
 }
+
+// Messages returns a copy of the buffer without modifying it
+func (mg *MsgHistory) Messages() []interface{} {
+	mg.mx.RLock()
+	defer mg.mx.RUnlock()
+	copied := make([]interface{}, len(mg.buffer))
+	copy(copied, mg.buffer)
+	return copied
+}
+
+// Remove deletes every message for which match returns true
+func (mg *MsgHistory) Remove(match func(msg interface{}) bool) {
+	mg.mx.Lock()
+	defer mg.mx.Unlock()
+	kept := mg.buffer[:0]
+	for _, msg := range mg.buffer {
+		if !match(msg) {
+			kept = append(kept, msg)
+		}
+	}
+	mg.buffer = kept
+}
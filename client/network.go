@@ -1,37 +1,235 @@
 package client
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"sort"
+	"sync"
 	"time"
 )
 
+// Network describes a blockchain network blocknative can watch
+type Network struct {
+	// ChainID is the EIP-155 chain id of the network, zero for systems
+	// without chain ids such as bitcoin
+	ChainID int64
+	// Name is the network name used by blocknative, such as "main" or "matic-main"
+	Name string
+	// System is the blockchain system, such as "ethereum"
+	System string
+	// Currency and Decimals describe the native currency
+	Currency string
+	Decimals int
+	// BlockTime is the average time between blocks
+	BlockTime time.Duration
+	// Deprecated is set for networks which have been shut down or are no
+	// longer supported by blocknative
+	Deprecated bool
+}
+
+// Blockchain returns the blockchain params identifying n in messages
+func (n Network) Blockchain() Blockchain {
+	return Blockchain{System: n.System, Network: n.Name}
+}
+
+// builtinNetworks are the networks known without calling RegisterNetwork
+var builtinNetworks = []Network{
+	{ChainID: 1, Name: "main", System: "ethereum", Currency: "ETH", Decimals: 18, BlockTime: 12 * time.Second},
+	{ChainID: 3, Name: "ropsten", System: "ethereum", Currency: "ETH", Decimals: 18, BlockTime: 12 * time.Second, Deprecated: true},
+	{ChainID: 4, Name: "rinkeby", System: "ethereum", Currency: "ETH", Decimals: 18, BlockTime: 15 * time.Second, Deprecated: true},
+	{ChainID: 5, Name: "goerli", System: "ethereum", Currency: "ETH", Decimals: 18, BlockTime: 12 * time.Second, Deprecated: true},
+	{ChainID: 10, Name: "optimism-main", System: "ethereum", Currency: "ETH", Decimals: 18, BlockTime: 2 * time.Second},
+	{ChainID: 42, Name: "kovan", System: "ethereum", Currency: "ETH", Decimals: 18, BlockTime: 4 * time.Second, Deprecated: true},
+	{ChainID: 56, Name: "bsc-main", System: "ethereum", Currency: "BNB", Decimals: 18, BlockTime: 3 * time.Second},
+	{ChainID: 100, Name: "xdai", System: "ethereum", Currency: "xDAI", Decimals: 18, BlockTime: 5 * time.Second},
+	{ChainID: 137, Name: "matic-main", System: "ethereum", Currency: "MATIC", Decimals: 18, BlockTime: 2 * time.Second},
+	{ChainID: 250, Name: "fantom-main", System: "ethereum", Currency: "FTM", Decimals: 18, BlockTime: time.Second},
+	{ChainID: 8453, Name: "base-main", System: "ethereum", Currency: "ETH", Decimals: 18, BlockTime: 2 * time.Second},
+	{ChainID: 17000, Name: "holesky", System: "ethereum", Currency: "ETH", Decimals: 18, BlockTime: 12 * time.Second},
+	{ChainID: 42161, Name: "arbitrum-main", System: "ethereum", Currency: "ETH", Decimals: 18, BlockTime: 250 * time.Millisecond},
+	{ChainID: 80001, Name: "matic-mumbai", System: "ethereum", Currency: "MATIC", Decimals: 18, BlockTime: 2 * time.Second, Deprecated: true},
+	{ChainID: 11155111, Name: "sepolia", System: "ethereum", Currency: "ETH", Decimals: 18, BlockTime: 12 * time.Second},
+	{Name: "main", System: "bitcoin", Currency: "BTC", Decimals: 8, BlockTime: 10 * time.Minute},
+	{Name: "testnet", System: "bitcoin", Currency: "BTC", Decimals: 8, BlockTime: 10 * time.Minute},
+}
+
+// networkRegistry holds the known networks by chain id, if they have one,
+// and by system and name
+type networkRegistry struct {
+	mtx    sync.RWMutex
+	byID   map[int64]Network
+	byName map[Blockchain]Network
+}
+
+var networks = newNetworkRegistry(builtinNetworks)
+
+func newNetworkRegistry(list []Network) *networkRegistry {
+	r := &networkRegistry{
+		byID:   make(map[int64]Network),
+		byName: make(map[Blockchain]Network),
+	}
+	for _, n := range list {
+		r.add(n)
+	}
+	return r
+}
+
+// add registers n, replacing the networks with its chain id or its system
+// and name. r.mtx must be held
+func (r *networkRegistry) add(n Network) {
+	if prev, ok := r.byID[n.ChainID]; ok {
+		delete(r.byName, prev.Blockchain())
+	}
+	if prev, ok := r.byName[n.Blockchain()]; ok && prev.ChainID != 0 {
+		delete(r.byID, prev.ChainID)
+	}
+	if n.ChainID != 0 {
+		r.byID[n.ChainID] = n
+	}
+	r.byName[n.Blockchain()] = n
+}
+
+// RegisterNetwork adds n to the known networks, so it can be used with
+// OnChain and NewBaseMessage, or OnBitcoin and NewBitcoinBaseMessage for
+// bitcoin networks. Only ethereum networks need a chain id. A network
+// registered with the chain id or system and name of a known network
+// replaces it
+func RegisterNetwork(n Network) error {
+	if n.Name == "" {
+		return errors.New("network name is required")
+	}
+	if n.System == "" {
+		n.System = SystemEthereum
+	}
+	if n.ChainID < 0 || n.ChainID == 0 && n.System == SystemEthereum {
+		return fmt.Errorf("invalid chain id %d for network %s", n.ChainID, n.Name)
+	}
+	networks.mtx.Lock()
+	defer networks.mtx.Unlock()
+	networks.add(n)
+	return nil
+}
+
+// NetworkByChainID returns the network with the given chain id
+func NetworkByChainID(id int64) (Network, error) {
+	networks.mtx.RLock()
+	defer networks.mtx.RUnlock()
+	n, ok := networks.byID[id]
+	if !ok {
+		return Network{}, fmt.Errorf("chain id %d: %w", id, ErrInvalidNetwork)
+	}
+	return n, nil
+}
+
+// NetworkByName returns the network blocknative calls name on the given
+// system, an empty system means ethereum
+func NetworkByName(system, name string) (Network, error) {
+	if system == "" {
+		system = SystemEthereum
+	}
+	networks.mtx.RLock()
+	defer networks.mtx.RUnlock()
+	n, ok := networks.byName[Blockchain{System: system, Network: name}]
+	if !ok {
+		return Network{}, fmt.Errorf("%s network %q: %w", system, name, ErrInvalidNetwork)
+	}
+	return n, nil
+}
+
+// ChainID returns the chain id of the ethereum network blocknative calls name
+func ChainID(name string) (int64, error) {
+	n, err := NetworkByName(SystemEthereum, name)
+	return n.ChainID, err
+}
+
+// Networks returns the known networks ordered by chain id, followed by
+// the networks without one ordered by system and name
+func Networks() []Network {
+	networks.mtx.RLock()
+	out := make([]Network, 0, len(networks.byName))
+	for _, n := range networks.byName {
+		out = append(out, n)
+	}
+	networks.mtx.RUnlock()
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if (a.ChainID == 0) != (b.ChainID == 0) {
+			return b.ChainID == 0
+		}
+		if a.ChainID != b.ChainID {
+			return a.ChainID < b.ChainID
+		}
+		if a.System != b.System {
+			return a.System < b.System
+		}
+		return a.Name < b.Name
+	})
+	return out
+}
+
 // NetName converts chain ID to network name (string)
+//
+// Deprecated: use NetworkByChainID
 func NetName(id int64) (string, error) {
-	var netName string
-	switch id {
-	case 1:
-		netName = "main"
-	case 3:
-		netName = "ropsten"
-	case 4:
-		netName = "rinkeby"
-	case 5:
-		netName = "goerli"
-	case 42:
-		netName = "kovan"
-	case 56:
-		netName = "bsc-main"
-	case 100:
-		netName = "xdai"
-	case 137:
-		netName = "matic-main"
-	case 250:
-		netName = "fantom-main"
-	default:
-		return "", fmt.Errorf("network not supported id: %v", id)
-	}
-	return netName, nil
+	n, err := NetworkByChainID(id)
+	return n.Name, err
+}
+
+// SubscribeOption customizes a subscription
+type SubscribeOption func(*subscribeOptions) error
+
+type subscribeOptions struct {
+	blockchain *Blockchain
+}
+
+// OnNetwork makes a subscription watch b instead of the blockchain the
+// client was initialized with, so one client can watch several networks
+func OnNetwork(b Blockchain) SubscribeOption {
+	return func(o *subscribeOptions) error {
+		o.blockchain = &b
+		return nil
+	}
+}
+
+// OnChain is like OnNetwork for the network with the given chain ID, see NetworkByChainID
+func OnChain(id int64) SubscribeOption {
+	return func(o *subscribeOptions) error {
+		n, err := NetworkByChainID(id)
+		if err != nil {
+			return err
+		}
+		b := n.Blockchain()
+		o.blockchain = &b
+		return nil
+	}
+}
+
+func newSubscribeOptions(opts []SubscribeOption) (subscribeOptions, error) {
+	var o subscribeOptions
+	for _, opt := range opts {
+		if err := opt(&o); err != nil {
+			return o, err
+		}
+	}
+	return o, nil
+}
+
+// apply sets the blockchain of base if one was chosen
+func (o subscribeOptions) apply(base BaseMessage) BaseMessage {
+	if o.blockchain != nil {
+		base.Blockchain = *o.blockchain
+	}
+	return base
+}
+
+// base returns the base message for a subscription made with opts
+func (c *Client) base(opts []SubscribeOption) (BaseMessage, error) {
+	o, err := newSubscribeOptions(opts)
+	if err != nil {
+		return BaseMessage{}, err
+	}
+	return o.apply(c.baseMessage()), nil
 }
 
 // NewBaseMessageMainnet returns a base message suitable for mainnet usage
@@ -43,7 +241,7 @@ func NewBaseMessageMainnet(apiKey string) BaseMessage {
 		Timestamp: time.Now(),
 		DappID:    apiKey,
 		Blockchain: Blockchain{
-			System:  "ethereum",
+			System:  SystemEthereum,
 			Network: "main",
 		},
 	}
@@ -54,16 +252,13 @@ func NewBaseMessage(apiKey string, netID int64) (BaseMessage, error) {
 	if apiKey == "" {
 		apiKey = os.Getenv("BLOCKNATIVE_DAPP_ID")
 	}
-	net, err := NetName(netID)
+	n, err := NetworkByChainID(netID)
 	if err != nil {
 		return BaseMessage{}, err
 	}
 	return BaseMessage{
-		Timestamp: time.Now(),
-		DappID:    apiKey,
-		Blockchain: Blockchain{
-			System:  "ethereum",
-			Network: net,
-		},
+		Timestamp:  time.Now(),
+		DappID:     apiKey,
+		Blockchain: n.Blockchain(),
 	}, nil
 }
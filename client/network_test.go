@@ -0,0 +1,81 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNetworks(t *testing.T) {
+	n, err := NetworkByChainID(11155111)
+	require.NoError(t, err)
+	require.Equal(t, "sepolia", n.Name)
+	require.Equal(t, Blockchain{System: "ethereum", Network: "sepolia"}, n.Blockchain())
+	require.False(t, n.Deprecated)
+
+	n, err = NetworkByChainID(3)
+	require.NoError(t, err)
+	require.True(t, n.Deprecated)
+
+	id, err := ChainID("matic-main")
+	require.NoError(t, err)
+	require.Equal(t, int64(137), id)
+	_, err = ChainID("nope")
+	require.ErrorIs(t, err, ErrInvalidNetwork)
+
+	name, err := NetName(1)
+	require.NoError(t, err)
+	require.Equal(t, "main", name)
+
+	_, err = NetworkByChainID(424242)
+	require.ErrorIs(t, err, ErrInvalidNetwork)
+	require.NoError(t, RegisterNetwork(Network{ChainID: 424242, Name: "custom-main", Currency: "CUS", Decimals: 18, BlockTime: time.Second}))
+	t.Cleanup(func() {
+		networks.mtx.Lock()
+		delete(networks.byID, 424242)
+		delete(networks.byName, Blockchain{System: "ethereum", Network: "custom-main"})
+		networks.mtx.Unlock()
+	})
+	msg, err := NewBaseMessage("key", 424242)
+	require.NoError(t, err)
+	require.Equal(t, "custom-main", msg.Network)
+	require.Equal(t, "ethereum", msg.System)
+
+	list := Networks()
+	for i := 1; i < len(list) && list[i].ChainID != 0; i++ {
+		require.Less(t, list[i-1].ChainID, list[i].ChainID)
+	}
+	require.Equal(t, Network{Name: "testnet", System: "bitcoin", Currency: "BTC", Decimals: 8, BlockTime: 10 * time.Minute}, list[len(list)-1])
+	require.Error(t, RegisterNetwork(Network{ChainID: 1}))
+	require.Error(t, RegisterNetwork(Network{Name: "no-chain-id"}))
+}
+
+func TestBitcoinNetworks(t *testing.T) {
+	n, err := NetworkByName(SystemBitcoin, "main")
+	require.NoError(t, err)
+	require.Equal(t, Blockchain{System: "bitcoin", Network: "main"}, n.Blockchain())
+	require.Equal(t, 8, n.Decimals)
+	// the ethereum network of the same name is unaffected
+	n, err = NetworkByName(SystemEthereum, "main")
+	require.NoError(t, err)
+	require.Equal(t, int64(1), n.ChainID)
+
+	// bitcoin networks don't need a chain id
+	_, err = NewBitcoinBaseMessage("key", "regtest")
+	require.ErrorIs(t, err, ErrInvalidNetwork)
+	require.NoError(t, RegisterNetwork(Network{Name: "regtest", System: SystemBitcoin, Currency: "BTC", Decimals: 8}))
+	t.Cleanup(func() {
+		networks.mtx.Lock()
+		delete(networks.byName, Blockchain{System: "bitcoin", Network: "regtest"})
+		networks.mtx.Unlock()
+	})
+	msg, err := NewBitcoinBaseMessage("key", "regtest")
+	require.NoError(t, err)
+	require.Equal(t, Blockchain{System: "bitcoin", Network: "regtest"}, msg.Blockchain)
+	var o subscribeOptions
+	require.NoError(t, OnBitcoin("regtest")(&o))
+	require.Equal(t, msg.Blockchain, *o.blockchain)
+	_, err = NetworkByChainID(0)
+	require.ErrorIs(t, err, ErrInvalidNetwork)
+}
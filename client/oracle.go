@@ -0,0 +1,362 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// GasOracle defaults, used when the matching GasOracleOpts field is zero
+const (
+	DefaultGasOracleWindow     = 2 * time.Minute
+	DefaultGasOracleMaxSamples = 10000
+	DefaultGasOracleInterval   = time.Second
+)
+
+// ErrNoGasSamples is returned by GasOracle.Suggest while the window holds no transactions
+var ErrNoGasSamples = errors.New("no pending transactions in gas oracle window")
+
+// GasOracleOpts configures a GasOracle
+type GasOracleOpts struct {
+	// Config is the config subscribed to for pending transactions. A zero
+	// Config subscribes to every pending transaction with a global config
+	Config Config
+	// Window is how long a transaction is counted after it was seen
+	Window time.Duration
+	// MaxSamples bounds the number of transactions in the window, the oldest
+	// are dropped first
+	MaxSamples int
+	// Interval is how often the percentiles are checked for changes
+	Interval time.Duration
+	// Subscribe chooses the network the config is watched on
+	Subscribe []SubscribeOption
+}
+
+func (o GasOracleOpts) withDefaults() GasOracleOpts {
+	if o.Window <= 0 {
+		o.Window = DefaultGasOracleWindow
+	}
+	if o.MaxSamples <= 0 {
+		o.MaxSamples = DefaultGasOracleMaxSamples
+	}
+	if o.Interval <= 0 {
+		o.Interval = DefaultGasOracleInterval
+	}
+	return o
+}
+
+// GasPercentiles are percentiles of a fee over the transactions in a
+// GasOracle window, in wei
+type GasPercentiles struct {
+	P10, P50, P90, P99 *big.Int
+}
+
+func (p GasPercentiles) equal(q GasPercentiles) bool {
+	return equalInts(p.P10, q.P10) && equalInts(p.P50, q.P50) && equalInts(p.P90, q.P90) && equalInts(p.P99, q.P99)
+}
+
+// GasStats summarizes the transactions in a GasOracle window
+type GasStats struct {
+	// PriorityFee are percentiles of the tip offered to the miner, the max
+	// priority fee of dynamic fee transactions and the gas price above the
+	// base fee of other transactions
+	PriorityFee GasPercentiles
+	// MaxFee are percentiles of the most transactions pay per gas
+	MaxFee GasPercentiles
+	// BaseFee is the latest base fee reported, nil if none was
+	BaseFee *big.Int
+	// Samples is the number of transactions in the window
+	Samples int
+	Time    time.Time
+}
+
+// GasSuggestion are fees for a dynamic fee transaction, in wei
+type GasSuggestion struct {
+	MaxPriorityFeePerGas *big.Int
+	MaxFeePerGas         *big.Int
+	// BaseFee is the latest base fee reported, nil if none was
+	BaseFee *big.Int
+	// Samples is the number of transactions the suggestion is based on
+	Samples int
+}
+
+// gasSample is the pricing of a pending transaction
+type gasSample struct {
+	hash        string
+	seen        time.Time
+	priorityFee *big.Int
+	maxFee      *big.Int
+}
+
+// GasOracle suggests gas fees from the transactions pending in the mempool,
+// as streamed by blocknative. It keeps the transactions seen within a
+// sliding window and reports percentiles of what they offer
+type GasOracle struct {
+	opts    GasOracleOpts
+	now     func() time.Time
+	changes chan GasStats
+	cancel  context.CancelFunc
+	done    chan struct{}
+
+	mtx         sync.Mutex
+	samples     []gasSample // oldest first
+	hashes      map[string]struct{}
+	baseFee     *big.Int
+	sorted      bool
+	priorityFee []*big.Int
+	maxFee      []*big.Int
+}
+
+// NewGasOracle subscribes to opts.Config and feeds the pending transactions
+// it matches to a new GasOracle. The oracle runs until ctx is done, Close is
+// called or the subscription ends
+func (c *Client) NewGasOracle(ctx context.Context, opts GasOracleOpts) (*GasOracle, error) {
+	cfg := opts.Config
+	if cfg.Scope == "" {
+		cfg = NewConfig("global", false, nil)
+		if err := cfg.AddFilter(Filter().Field("status").Eq(string(StatusPending))); err != nil {
+			return nil, err
+		}
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	sub, err := c.NewEventSubscription(ctx, NewConfiguration(c.baseMessage(), cfg), opts.Subscribe...)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	o := newGasOracle(opts)
+	o.cancel = cancel
+	go o.run(ctx, sub)
+	return o, nil
+}
+
+func newGasOracle(opts GasOracleOpts) *GasOracle {
+	return &GasOracle{
+		opts:    opts.withDefaults(),
+		now:     time.Now,
+		changes: make(chan GasStats, 1),
+		cancel:  func() {},
+		done:    make(chan struct{}),
+		hashes:  make(map[string]struct{}),
+	}
+}
+
+// Changes delivers the stats of the window whenever its percentiles or the
+// base fee change. Only the latest stats are kept for a slow reader. The
+// channel is closed when the oracle stops
+func (o *GasOracle) Changes() <-chan GasStats {
+	return o.changes
+}
+
+// Done is closed when the oracle stops
+func (o *GasOracle) Done() <-chan struct{} {
+	return o.done
+}
+
+// Close stops the oracle and unsubscribes its config
+func (o *GasOracle) Close() {
+	o.cancel()
+	<-o.done
+}
+
+// Observe adds a pending transaction to the window. Events other than
+// txPool, and transactions already in the window, are ignored apart from
+// their base fee. Legacy transactions are only counted once a base fee is
+// known, as their priority fee depends on it
+func (o *GasOracle) Observe(ev EthTxPayload) {
+	t := &ev.Event.Transaction
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+	if t.BaseFeePerGas.IsSet() {
+		o.baseFee = t.BaseFeePerGas.Int()
+	}
+	if ev.Event.EventCode != EventTxPool {
+		return
+	}
+	if _, ok := o.hashes[registryKey(SystemEthereum, t.Hash)]; ok && t.Hash != "" {
+		return
+	}
+	g, err := ParseGasInfo(&ev)
+	if err != nil {
+		return
+	}
+	s := gasSample{hash: registryKey(SystemEthereum, t.Hash), seen: o.now(), maxFee: g.GasFeeCap, priorityFee: g.GasTipCap}
+	if t.Type < types.DynamicFeeTxType && t.GasPrice.IsSet() {
+		// a legacy gas price tips whatever is left above the base fee
+		if g.BaseFee == nil && o.baseFee != nil {
+			g = g.WithBaseFee(o.baseFee)
+		}
+		if g.EffectiveTip == nil {
+			return
+		}
+		s.priorityFee = g.EffectiveTip
+	}
+	o.samples = append(o.samples, s)
+	if s.hash != "" {
+		o.hashes[s.hash] = struct{}{}
+	}
+	o.sorted = false
+	o.prune()
+}
+
+// Stats returns the stats of the transactions currently in the window
+func (o *GasOracle) Stats() GasStats {
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+	o.prune()
+	o.sort()
+	st := GasStats{Samples: len(o.samples), Time: o.now()}
+	if o.baseFee != nil {
+		st.BaseFee = new(big.Int).Set(o.baseFee)
+	}
+	if len(o.samples) == 0 {
+		return st
+	}
+	st.PriorityFee = percentiles(o.priorityFee)
+	st.MaxFee = percentiles(o.maxFee)
+	return st
+}
+
+// Suggest returns fees which, judging by the transactions in the window,
+// outbid the given fraction of pending transactions. confidence is between
+// 0 and 1, 0.9 suggests the 90th percentile of priority and max fees. The
+// max fee is raised to cover the latest base fee plus the priority fee
+func (o *GasOracle) Suggest(confidence float64) (GasSuggestion, error) {
+	if confidence <= 0 || confidence > 1 {
+		return GasSuggestion{}, fmt.Errorf("confidence %v is not in (0, 1]", confidence)
+	}
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+	o.prune()
+	if len(o.samples) == 0 {
+		return GasSuggestion{}, ErrNoGasSamples
+	}
+	o.sort()
+	s := GasSuggestion{
+		MaxPriorityFeePerGas: percentile(o.priorityFee, confidence),
+		MaxFeePerGas:         percentile(o.maxFee, confidence),
+		Samples:              len(o.samples),
+	}
+	if o.baseFee != nil {
+		s.BaseFee = new(big.Int).Set(o.baseFee)
+		if floor := new(big.Int).Add(o.baseFee, s.MaxPriorityFeePerGas); s.MaxFeePerGas.Cmp(floor) < 0 {
+			s.MaxFeePerGas = floor
+		}
+	}
+	return s, nil
+}
+
+// run feeds the events of sub to o and publishes changes every interval
+func (o *GasOracle) run(ctx context.Context, sub *Subscription[EthTxPayload]) {
+	defer close(o.done)
+	defer close(o.changes)
+	ticker := time.NewTicker(o.opts.Interval)
+	defer ticker.Stop()
+	var last GasStats
+	for {
+		select {
+		case ev, ok := <-sub.Events():
+			if !ok {
+				return
+			}
+			o.Observe(ev)
+		case <-sub.Err():
+		case <-ticker.C:
+			st := o.Stats()
+			if st.Samples == 0 || (st.PriorityFee.equal(last.PriorityFee) && st.MaxFee.equal(last.MaxFee) && equalInts(st.BaseFee, last.BaseFee)) {
+				continue
+			}
+			last = st
+			o.publish(st)
+		case <-ctx.Done():
+			// the subscription is unwatched as ctx is done
+			return
+		}
+	}
+}
+
+// publish replaces any unread stats with st, only run sends on o.changes
+func (o *GasOracle) publish(st GasStats) {
+	select {
+	case o.changes <- st:
+		return
+	default:
+	}
+	select {
+	case <-o.changes:
+	default:
+	}
+	select {
+	case o.changes <- st:
+	default:
+	}
+}
+
+// prune drops the samples which fell out of the window, o.mtx must be held
+func (o *GasOracle) prune() {
+	cutoff := o.now().Add(-o.opts.Window)
+	n := 0
+	for n < len(o.samples) && (o.samples[n].seen.Before(cutoff) || len(o.samples)-n > o.opts.MaxSamples) {
+		delete(o.hashes, o.samples[n].hash)
+		n++
+	}
+	if n > 0 {
+		o.samples = o.samples[n:]
+		o.sorted = false
+	}
+}
+
+// sort orders the fees of the window, o.mtx must be held
+func (o *GasOracle) sort() {
+	if o.sorted {
+		return
+	}
+	o.priorityFee = o.priorityFee[:0]
+	o.maxFee = o.maxFee[:0]
+	for _, s := range o.samples {
+		o.priorityFee = append(o.priorityFee, s.priorityFee)
+		o.maxFee = append(o.maxFee, s.maxFee)
+	}
+	sortInts(o.priorityFee)
+	sortInts(o.maxFee)
+	o.sorted = true
+}
+
+func sortInts(xs []*big.Int) {
+	sort.Slice(xs, func(i, j int) bool { return xs[i].Cmp(xs[j]) < 0 })
+}
+
+func percentiles(sorted []*big.Int) GasPercentiles {
+	return GasPercentiles{
+		P10: percentile(sorted, 0.10),
+		P50: percentile(sorted, 0.50),
+		P90: percentile(sorted, 0.90),
+		P99: percentile(sorted, 0.99),
+	}
+}
+
+// percentile returns the nearest rank p percentile of sorted, which must not be empty
+func percentile(sorted []*big.Int, p float64) *big.Int {
+	i := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(sorted) {
+		i = len(sorted) - 1
+	}
+	return new(big.Int).Set(sorted[i])
+}
+
+func equalInts(a, b *big.Int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Cmp(b) == 0
+}
@@ -0,0 +1,117 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ATMackay/go-blocknative/client/blocknativetest"
+	"github.com/stretchr/testify/require"
+)
+
+func pendingTx(hash string, maxFee, tip int64) EthTxPayload {
+	var ev EthTxPayload
+	ev.Event.EventCode = EventTxPool
+	ev.Event.Transaction.Type = 2
+	ev.Event.Transaction.Hash = hash
+	ev.Event.Transaction.MaxFeePerGas = NewBigInt(big.NewInt(maxFee))
+	ev.Event.Transaction.MaxPriorityFeePerGas = NewBigInt(big.NewInt(tip))
+	return ev
+}
+
+func TestGasOracle(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	o := newGasOracle(GasOracleOpts{Window: time.Minute, MaxSamples: 150})
+	o.now = func() time.Time { return now }
+
+	_, err := o.Suggest(0.5)
+	require.ErrorIs(t, err, ErrNoGasSamples)
+	_, err = o.Suggest(0)
+	require.Error(t, err)
+
+	// tips of 1..100 gwei, max fees of 101..200 gwei
+	for i := int64(1); i <= 100; i++ {
+		o.Observe(pendingTx(fmt.Sprintf("0x%x", i), (100+i)*1e9, i*1e9))
+	}
+	// repeated and non pending events are ignored
+	o.Observe(pendingTx("0x1", 1, 1))
+	confirmed := pendingTx("0xff", 1, 1)
+	confirmed.Event.EventCode = EventTxConfirmed
+	o.Observe(confirmed)
+
+	st := o.Stats()
+	require.Equal(t, 100, st.Samples)
+	require.Nil(t, st.BaseFee)
+	require.Equal(t, GasPercentiles{P10: big.NewInt(10e9), P50: big.NewInt(50e9), P90: big.NewInt(90e9), P99: big.NewInt(99e9)}, st.PriorityFee)
+	require.Equal(t, big.NewInt(150e9), st.MaxFee.P50)
+
+	s, err := o.Suggest(0.9)
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(90e9), s.MaxPriorityFeePerGas)
+	require.Equal(t, big.NewInt(190e9), s.MaxFeePerGas)
+
+	// the max fee covers the base fee plus the tip
+	withBaseFee := pendingTx("0x100", 300e9, 1)
+	withBaseFee.Event.EventCode = EventTxConfirmed
+	withBaseFee.Event.Transaction.BaseFeePerGas = NewBigInt(big.NewInt(150e9))
+	o.Observe(withBaseFee)
+	s, err = o.Suggest(0.9)
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(150e9), s.BaseFee)
+	require.Equal(t, big.NewInt(240e9), s.MaxFeePerGas)
+
+	// legacy transactions tip their gas price above the base fee
+	legacy := pendingTx("0x200", 0, 0)
+	legacy.Event.Transaction.Type = 0
+	legacy.Event.Transaction.GasPrice = NewBigInt(big.NewInt(151e9))
+	o.Observe(legacy)
+	require.Equal(t, big.NewInt(1e9), o.samples[len(o.samples)-1].priorityFee)
+
+	// samples leave the window as they age and beyond MaxSamples
+	now = now.Add(30 * time.Second)
+	for i := int64(1); i <= 100; i++ {
+		o.Observe(pendingTx(fmt.Sprintf("0x1%03d", i), 1e9, 1e9))
+	}
+	require.Equal(t, 150, o.Stats().Samples)
+	now = now.Add(45 * time.Second)
+	st = o.Stats()
+	require.Equal(t, 100, st.Samples)
+	require.Equal(t, big.NewInt(1e9), st.PriorityFee.P99)
+}
+
+func TestClientGasOracle(t *testing.T) {
+	cl, srv := newFakeClient(t, Opts{})
+	o, err := cl.NewGasOracle(context.Background(), GasOracleOpts{Interval: time.Millisecond})
+	require.NoError(t, err)
+	msg, err := srv.WaitForMessage(time.Second, "configs", "put", "global")
+	require.NoError(t, err)
+	require.Contains(t, string(msg.Raw), `"status":"pending"`)
+
+	for i, tip := range []string{"1000000000", "2000000000", "3000000000"} {
+		require.NoError(t, srv.SendTx(blocknativetest.TxEvent{EventCode: "txPool", Transaction: map[string]interface{}{
+			"hash": fmt.Sprintf("0x%d", i), "type": 2, "maxFeePerGas": "50000000000", "maxPriorityFeePerGas": tip,
+		}}))
+	}
+	timeout := time.After(time.Second)
+	var st GasStats
+	for st.Samples < 3 {
+		select {
+		case st = <-o.Changes():
+		case <-timeout:
+			t.Fatal("no change notified")
+		}
+	}
+	require.Equal(t, big.NewInt(2e9), st.PriorityFee.P50)
+	require.Equal(t, big.NewInt(3e9), st.PriorityFee.P99)
+	s, err := o.Suggest(1)
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(3e9), s.MaxPriorityFeePerGas)
+
+	o.Close()
+	_, ok := <-o.Changes()
+	require.False(t, ok)
+	_, err = srv.WaitForMessage(time.Second, "configs", "unwatch", "global")
+	require.NoError(t, err)
+}
@@ -0,0 +1,452 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// DefaultMaxPerConnection is the default number of subscriptions a Pool
+// places on a single connection
+const DefaultMaxPerConnection = 1000
+
+var errPoolClosed = errors.New("pool closed")
+
+// PoolOpts configures a Pool
+type PoolOpts struct {
+	// Opts configures every connection of the pool
+	Opts
+	// Connections is the number of connections opened by NewPool, at least one
+	Connections int
+	// MaxPerConnection caps the subscriptions placed on a single connection,
+	// zero uses DefaultMaxPerConnection
+	MaxPerConnection int
+	// MaxConnections bounds the number of connections, zero allows the pool
+	// to open as many as its subscriptions need
+	MaxConnections int
+	// Merge delivers the events of every subscription on the stream
+	// returned by Merged rather than on the subscriptions themselves
+	Merge bool
+}
+
+// PoolEvent is an event on the merged stream of a Pool, tagged with the
+// key of the subscription it was routed to
+type PoolEvent struct {
+	Key   string
+	Event EthTxPayload
+}
+
+// Pool spreads subscriptions over several connections to blocknative,
+// which limits the number of entities watched per connection. New
+// subscriptions are placed on the least loaded connection below
+// MaxPerConnection and further connections are opened as needed. When a
+// connection is lost for good its subscriptions are moved to the others,
+// keeping the Subscription values handed out by the pool
+type Pool struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	opts   PoolOpts
+	merged *Subscription[PoolEvent]
+
+	mtx     sync.Mutex // guards everything below
+	initMsg *BaseMessage
+	conns   []*poolConn
+	subs    map[string]*poolSub
+	closed  bool
+}
+
+// poolConn is a connection of a Pool and the number of subscriptions placed on it
+type poolConn struct {
+	client *Client
+	subs   int
+}
+
+// poolSub is a subscription handed out by a Pool
+type poolSub struct {
+	sub    *Subscription[EthTxPayload]
+	kind   SubscriptionKind
+	config *Configuration
+	opts   subscribeOptions
+	id     string // network, kind and key, see entryKey
+	// conn and member are where the subscription is currently placed
+	conn   *poolConn
+	member *poolMember
+}
+
+// messages returns the subscribe and unsubscribe messages of s
+func (s *poolSub) messages(base BaseMessage) (interface{}, interface{}) {
+	base = s.opts.apply(base)
+	switch s.kind {
+	case KindAddress:
+		return NewAddressSubscribe(base, s.sub.Key()), NewAddressUnsubscribe(base, s.sub.Key())
+	case KindTransaction:
+		return NewTxSubscribe(base, s.sub.Key()), NewTxUnsubscribe(base, s.sub.Key())
+	}
+	msg := *s.config
+	if s.opts.blockchain != nil || msg.Network == "" {
+		msg.Blockchain = base.Blockchain
+	}
+	base.Blockchain = msg.Blockchain
+	return msg, NewEventUnsubscribe(base, msg.Config)
+}
+
+// NewPool opens opts.Connections connections to blocknative
+func NewPool(ctx context.Context, opts PoolOpts) (*Pool, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	p := &Pool{
+		ctx:    ctx,
+		cancel: cancel,
+		opts:   opts,
+		subs:   make(map[string]*poolSub),
+	}
+	if opts.Merge {
+		p.merged = NewSubscription[PoolEvent]("")
+	}
+	for i := 0; i < opts.Connections || i == 0; i++ {
+		pc, err := p.dial()
+		if err != nil {
+			p.Close()
+			return nil, err
+		}
+		p.conns = append(p.conns, pc)
+	}
+	return p, nil
+}
+
+// Initialize initializes every connection of the pool with msg, it is
+// also used for connections opened later on
+func (p *Pool) Initialize(msg BaseMessage) error {
+	p.mtx.Lock()
+	p.initMsg = &msg
+	conns := append([]*poolConn{}, p.conns...)
+	p.mtx.Unlock()
+	for _, pc := range conns {
+		if err := pc.client.Initialize(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Merged returns the stream of every event received by the pool's
+// subscriptions, nil unless PoolOpts.Merge is set. Events are forwarded to
+// the merged stream instead of their subscription, so only it needs to be
+// drained. Errors are still reported on the subscriptions
+func (p *Pool) Merged() *Subscription[PoolEvent] {
+	return p.merged
+}
+
+// Load returns the number of subscriptions placed on each connection
+func (p *Pool) Load() []int {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	out := make([]int, len(p.conns))
+	for i, pc := range p.conns {
+		out[i] = pc.subs
+	}
+	return out
+}
+
+// NewAddressSubscription is like Client.NewAddressSubscription
+func (p *Pool) NewAddressSubscription(ctx context.Context, address string, opts ...SubscribeOption) (*Subscription[EthTxPayload], error) {
+	return p.subscribe(ctx, &poolSub{sub: NewSubscription[EthTxPayload](address), kind: KindAddress}, opts)
+}
+
+// NewTransactionSubscription is like Client.NewTransactionSubscription
+func (p *Pool) NewTransactionSubscription(ctx context.Context, txHash string, opts ...SubscribeOption) (*Subscription[EthTxPayload], error) {
+	return p.subscribe(ctx, &poolSub{sub: NewSubscription[EthTxPayload](txHash), kind: KindTransaction}, opts)
+}
+
+// NewEventSubscription is like Client.NewEventSubscription
+func (p *Pool) NewEventSubscription(ctx context.Context, msg Configuration, opts ...SubscribeOption) (*Subscription[EthTxPayload], error) {
+	msg, contract, err := prepareConfig(msg)
+	if err != nil {
+		return nil, err
+	}
+	sub := NewSubscription[EthTxPayload](msg.Scope)
+	sub.contract = contract
+	return p.subscribe(ctx, &poolSub{sub: sub, kind: KindConfig, config: &msg}, opts)
+}
+
+// Close closes every connection and subscription of the pool
+func (p *Pool) Close() error {
+	p.mtx.Lock()
+	if p.closed {
+		p.mtx.Unlock()
+		return nil
+	}
+	p.closed = true
+	conns, subs := p.conns, p.subs
+	p.conns, p.subs = nil, make(map[string]*poolSub)
+	p.mtx.Unlock()
+
+	p.cancel()
+	var first error
+	for _, pc := range conns {
+		if err := pc.client.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	for _, ps := range subs {
+		ps.sub.close()
+	}
+	if p.merged != nil {
+		p.merged.close()
+	}
+	return first
+}
+
+func (p *Pool) subscribe(ctx context.Context, ps *poolSub, opts []SubscribeOption) (*Subscription[EthTxPayload], error) {
+	var err error
+	if ps.opts, err = newSubscribeOptions(opts); err != nil {
+		return nil, err
+	}
+	var base BaseMessage
+	p.mtx.Lock()
+	if p.initMsg != nil {
+		base = *p.initMsg
+	}
+	p.mtx.Unlock()
+	subMsg, _ := ps.messages(base)
+	if err := validateMessage(subMsg); err != nil {
+		return nil, err
+	}
+	ps.id = messageID(subMsg)
+	if err := p.place(ctx, ps); err != nil {
+		return nil, err
+	}
+	p.mtx.Lock()
+	prev := p.subs[ps.id]
+	p.subs[ps.id] = ps
+	if prev != nil && prev.conn == ps.conn {
+		// the connection has already replaced prev's registration
+		prev.conn.subs--
+		prev.conn, prev.member = nil, nil
+	}
+	p.mtx.Unlock()
+	if prev != nil {
+		prev.sub.Unsubscribe()
+	}
+	ps.sub.setUnsubscribe(func(ctx context.Context) error {
+		return p.unsubscribe(ctx, ps)
+	})
+	go func() {
+		select {
+		case <-ctx.Done():
+			ps.sub.UnsubscribeContext(p.ctx)
+		case <-ps.sub.closed():
+		}
+	}()
+	return ps.sub, nil
+}
+
+// place subscribes ps on the least loaded connection with room for it,
+// opening a new connection if there is none
+func (p *Pool) place(ctx context.Context, ps *poolSub) error {
+	var pc *poolConn
+	for pc == nil {
+		p.mtx.Lock()
+		if p.closed {
+			p.mtx.Unlock()
+			return errPoolClosed
+		}
+		if pc = p.leastLoaded(); pc != nil {
+			pc.subs++
+			p.mtx.Unlock()
+			break
+		}
+		full := p.opts.MaxConnections > 0 && len(p.conns) >= p.opts.MaxConnections
+		p.mtx.Unlock()
+		if full {
+			return fmt.Errorf("all %d connections are full: %w", p.opts.MaxConnections, ErrSubscriptionLimit)
+		}
+		conn, err := p.dial()
+		if err != nil {
+			return err
+		}
+		p.mtx.Lock()
+		if p.closed {
+			p.mtx.Unlock()
+			conn.client.Close()
+			return errPoolClosed
+		}
+		p.conns = append(p.conns, conn)
+		p.mtx.Unlock()
+	}
+
+	m := &poolMember{ps: ps, conn: pc, merged: p.merged, done: make(chan struct{})}
+	subMsg, unsubMsg := ps.messages(pc.client.baseMessage())
+	if err := pc.client.confirm(ctx, m, subMsg, unsubMsg); err != nil {
+		p.mtx.Lock()
+		pc.subs--
+		p.mtx.Unlock()
+		return err
+	}
+	p.mtx.Lock()
+	ps.conn, ps.member = pc, m
+	p.mtx.Unlock()
+	return nil
+}
+
+// leastLoaded returns the connection with the fewest subscriptions below
+// the cap, p.mtx must be held
+func (p *Pool) leastLoaded() *poolConn {
+	max := p.opts.MaxPerConnection
+	if max <= 0 {
+		max = DefaultMaxPerConnection
+	}
+	var best *poolConn
+	for _, pc := range p.conns {
+		if pc.subs < max && (best == nil || pc.subs < best.subs) {
+			best = pc
+		}
+	}
+	return best
+}
+
+// dial opens and initializes a connection
+func (p *Pool) dial() (*poolConn, error) {
+	cl, err := New(p.ctx, p.opts.Opts)
+	if err != nil {
+		return nil, err
+	}
+	p.mtx.Lock()
+	initMsg := p.initMsg
+	p.mtx.Unlock()
+	if initMsg != nil {
+		if err := cl.Initialize(*initMsg); err != nil {
+			cl.Close()
+			return nil, err
+		}
+	}
+	pc := &poolConn{client: cl}
+	go func() {
+		select {
+		case <-cl.done:
+			p.lost(pc)
+		case <-p.ctx.Done():
+		}
+	}()
+	return pc, nil
+}
+
+func (p *Pool) unsubscribe(ctx context.Context, ps *poolSub) error {
+	p.mtx.Lock()
+	if p.subs[ps.id] == ps {
+		delete(p.subs, ps.id)
+	}
+	m := ps.member
+	if ps.conn != nil {
+		ps.conn.subs--
+	}
+	ps.conn, ps.member = nil, nil
+	p.mtx.Unlock()
+	if m == nil {
+		return nil
+	}
+	return m.UnsubscribeContext(ctx)
+}
+
+// lost moves the subscriptions of a connection which has shut down to the
+// remaining connections. Subscriptions which can't be moved fail
+func (p *Pool) lost(pc *poolConn) {
+	p.mtx.Lock()
+	if p.closed {
+		p.mtx.Unlock()
+		return
+	}
+	for i := range p.conns {
+		if p.conns[i] == pc {
+			p.conns = append(p.conns[:i], p.conns[i+1:]...)
+			break
+		}
+	}
+	var moved []*poolSub
+	for _, ps := range p.subs {
+		if ps.conn == pc {
+			ps.conn, ps.member = nil, nil
+			moved = append(moved, ps)
+		}
+	}
+	p.mtx.Unlock()
+
+	for _, ps := range moved {
+		if err := p.place(p.ctx, ps); err != nil {
+			ps.sub.fail(fmt.Errorf("failed to move subscription after connection loss: %w", err))
+			ps.sub.Unsubscribe()
+			continue
+		}
+		p.mtx.Lock()
+		current := p.subs[ps.id] == ps
+		p.mtx.Unlock()
+		if !current {
+			// unsubscribed while being moved
+			p.unsubscribe(p.ctx, ps)
+		}
+	}
+}
+
+// poolMember is the registration of a pool subscription on one connection.
+// It forwards events to the subscription handed out by the pool, which
+// outlives the connection
+type poolMember struct {
+	ps          *poolSub
+	conn        *poolConn
+	merged      *Subscription[PoolEvent]
+	done        chan struct{}
+	closeOnce   sync.Once
+	unsubOnce   sync.Once
+	unsubErr    error
+	unsubscribe func(ctx context.Context) error
+}
+
+func (m *poolMember) Key() string {
+	return m.ps.sub.Key()
+}
+
+func (m *poolMember) Unsubscribe() error {
+	return m.UnsubscribeContext(context.Background())
+}
+
+func (m *poolMember) UnsubscribeContext(ctx context.Context) error {
+	m.unsubOnce.Do(func() {
+		if m.unsubscribe != nil {
+			m.unsubErr = m.unsubscribe(ctx)
+		}
+		m.close()
+	})
+	return m.unsubErr
+}
+
+func (m *poolMember) deliver(ctx context.Context, data []byte) {
+	ev, ok := m.ps.sub.decode(data)
+	if !ok {
+		return
+	}
+	if m.merged != nil {
+		m.merged.emit(PoolEvent{Key: m.Key(), Event: ev})
+		return
+	}
+	m.ps.sub.emit(ev)
+}
+
+func (m *poolMember) fail(err error) {
+	if m.conn.client.readErr() != nil {
+		// the connection is gone, the pool moves the subscription elsewhere
+		return
+	}
+	m.ps.sub.fail(err)
+}
+
+func (m *poolMember) close() {
+	m.closeOnce.Do(func() { close(m.done) })
+}
+
+func (m *poolMember) closed() <-chan struct{} {
+	return m.done
+}
+
+func (m *poolMember) setUnsubscribe(f func(ctx context.Context) error) {
+	m.unsubscribe = f
+}
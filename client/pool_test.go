@@ -0,0 +1,118 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ATMackay/go-blocknative/client/blocknativetest"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPool(t *testing.T) {
+	srv := blocknativetest.NewServer()
+	defer srv.Close()
+	pool, err := NewPool(context.Background(), PoolOpts{
+		Opts:             Opts{Scheme: "ws", Host: srv.Host()},
+		MaxPerConnection: 2,
+		Merge:            true,
+	})
+	require.NoError(t, err)
+	defer pool.Close()
+	require.NoError(t, pool.Initialize(NewBaseMessageMainnet("test-key")))
+
+	subs := make(map[string]*Subscription[EthTxPayload])
+	for i := 1; i <= 5; i++ {
+		address := fmt.Sprintf("0x%040x", i)
+		sub, err := pool.NewAddressSubscription(context.Background(), address)
+		require.NoError(t, err)
+		subs[address] = sub
+	}
+	require.Equal(t, []int{2, 2, 1}, pool.Load())
+	require.Equal(t, 3, srv.Connections())
+
+	expect := func(address string) {
+		t.Helper()
+		require.NoError(t, srv.SendTx(blocknativetest.TxEvent{Transaction: map[string]interface{}{"hash": "0x01", "watchedAddress": address}}))
+		select {
+		case ev := <-pool.Merged().Events():
+			require.Equal(t, address, ev.Key)
+			require.Equal(t, address, ev.Event.Event.Transaction.WatchedAddress)
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for merged event")
+		}
+		// events are forwarded rather than duplicated
+		require.Len(t, subs[address].Events(), 0)
+	}
+	for address := range subs {
+		expect(address)
+	}
+
+	// the subscriptions of a lost connection move to the others
+	var dropped []string
+	for _, m := range srv.Received() {
+		if m.Conn == 1 && m.EventCode == "watch" {
+			dropped = append(dropped, m.Key())
+		}
+	}
+	require.Len(t, dropped, 2)
+	require.True(t, srv.Drop(1))
+	for _, address := range dropped {
+		_, err := srv.WaitFor(context.Background(), func(m blocknativetest.Message) bool {
+			return m.Conn != 1 && m.EventCode == "watch" && m.Key() == address
+		})
+		require.NoError(t, err)
+	}
+	require.Equal(t, 5, sum(pool.Load()))
+	for address := range subs {
+		expect(address)
+	}
+
+	// unsubscribing frees the slot
+	require.NoError(t, subs[dropped[0]].Unsubscribe())
+	require.Equal(t, 4, sum(pool.Load()))
+	require.Len(t, srv.Watched(), 4)
+}
+
+func TestPoolDecodedCall(t *testing.T) {
+	contract, err := abi.JSON(strings.NewReader(erc20ABI))
+	require.NoError(t, err)
+	cfg, err := NewABIConfig("global", false, contract)
+	require.NoError(t, err)
+	to := common.HexToAddress("0xfa6de2697d59e88ed7fc4dfe5a33dac43565ea41")
+	input, err := contract.Pack("transfer", to, big.NewInt(42))
+	require.NoError(t, err)
+
+	srv := blocknativetest.NewServer()
+	defer srv.Close()
+	pool, err := NewPool(context.Background(), PoolOpts{Opts: Opts{Scheme: "ws", Host: srv.Host()}})
+	require.NoError(t, err)
+	defer pool.Close()
+	require.NoError(t, pool.Initialize(NewBaseMessageMainnet("test-key")))
+	sub, err := pool.NewEventSubscription(context.Background(), NewConfiguration(NewBaseMessageMainnet("test-key"), cfg))
+	require.NoError(t, err)
+
+	require.NoError(t, srv.SendTx(blocknativetest.TxEvent{Transaction: map[string]interface{}{"hash": "0x01", "input": hexutil.Encode(input)}}))
+	select {
+	case ev := <-sub.Events():
+		require.NotNil(t, ev.Event.Call)
+		require.Equal(t, "transfer", ev.Event.Call.Name())
+		require.Equal(t, big.NewInt(42), ev.Event.Call.Args["_value"])
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for event")
+	}
+}
+
+func sum(xs []int) int {
+	var n int
+	for _, x := range xs {
+		n += x
+	}
+	return n
+}
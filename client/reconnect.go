@@ -0,0 +1,198 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/url"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// DefaultBackoff is used for any zero valued Backoff fields
+var DefaultBackoff = Backoff{
+	InitialInterval: 500 * time.Millisecond,
+	MaxInterval:     30 * time.Second,
+	Multiplier:      2,
+	Jitter:          0.2,
+}
+
+// Backoff configures how long the client waits between reconnect attempts.
+// The interval grows exponentially from InitialInterval up to MaxInterval
+// and each wait is randomly shortened by up to Jitter (0-1) of its length
+type Backoff struct {
+	InitialInterval time.Duration
+	MaxInterval     time.Duration
+	Multiplier      float64
+	Jitter          float64
+	// MaxAttempts bounds the number of redials, zero retries until the client is closed
+	MaxAttempts int
+}
+
+// Delay returns the wait before reconnect attempt n (starting at zero)
+func (b Backoff) Delay(n int) time.Duration {
+	b = b.withDefaults()
+	d := float64(b.InitialInterval)
+	for i := 0; i < n && d < float64(b.MaxInterval); i++ {
+		d *= b.Multiplier
+	}
+	if d > float64(b.MaxInterval) {
+		d = float64(b.MaxInterval)
+	}
+	return time.Duration(d * (1 - b.Jitter*rand.Float64()))
+}
+
+func (b Backoff) withDefaults() Backoff {
+	if b.InitialInterval <= 0 {
+		b.InitialInterval = DefaultBackoff.InitialInterval
+	}
+	if b.MaxInterval <= 0 {
+		b.MaxInterval = DefaultBackoff.MaxInterval
+	}
+	if b.Multiplier < 1 {
+		b.Multiplier = DefaultBackoff.Multiplier
+	}
+	if b.Jitter <= 0 || b.Jitter > 1 {
+		b.Jitter = DefaultBackoff.Jitter
+	}
+	return b
+}
+
+// dial opens a websocket connection and checks blocknative's connect response
+func dial(ctx context.Context, opts Opts) (*websocket.Conn, *ConnectResponse, error) {
+	u := url.URL{
+		Scheme: opts.Scheme,
+		Host:   opts.Host,
+		Path:   opts.Path,
+	}
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, u.String(), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	// this checks out connection to blocknative's api and makes sure that we connected properly
+	var out ConnectResponse
+	if err := readFrame(conn, opts.Recorder, &out); err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	if out.Status != "ok" {
+		conn.Close()
+		return nil, nil, fmt.Errorf("failed to initialize websockets connection: %w", NewServerError(out.Reason))
+	}
+	return conn, &out, nil
+}
+
+// readFrame reads the next frame from conn into out, recording it when rec is set
+func readFrame(conn *websocket.Conn, rec *Recorder, out interface{}) error {
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		return err
+	}
+	rec.Record(Inbound, data)
+	return json.Unmarshal(data, out)
+}
+
+// reconnect redials blocknative after the connection dropped with cause,
+// then restores the session so existing subscriptions keep receiving events
+func (c *Client) reconnect(cause error) error {
+	backoff := c.opts.Backoff
+	for attempt := 0; backoff.MaxAttempts <= 0 || attempt < backoff.MaxAttempts; attempt++ {
+		select {
+		case <-time.After(backoff.Delay(attempt)):
+		case <-c.ctx.Done():
+			return cause
+		}
+		conn, _, err := dial(c.ctx, c.opts)
+		if err != nil {
+			continue
+		}
+		if err := c.resume(conn); err != nil {
+			conn.Close()
+			continue
+		}
+		return nil
+	}
+	return fmt.Errorf("failed to reconnect after %d attempts: %w", backoff.MaxAttempts, cause)
+}
+
+// resume resends the initialization message and replays the message history
+// on conn before making it the client's connection
+func (c *Client) resume(conn *websocket.Conn) error {
+	if msg := c.baseMessage(); msg.CategoryCode != "" {
+		if err := c.writeFrame(conn, &msg); err != nil {
+			return err
+		}
+		conn.SetReadDeadline(time.Now().Add(ackTimeout))
+		var out ConnectResponse
+		if err := readFrame(conn, c.opts.Recorder, &out); err != nil {
+			return err
+		}
+		conn.SetReadDeadline(time.Time{})
+		if out.Status != "ok" {
+			return fmt.Errorf("failed to initialize api connection: %w", NewServerError(out.Reason))
+		}
+	}
+	c.keepalive(conn)
+	var acks []func(*frameHeader) bool
+	for _, msg := range c.history.Messages() {
+		if err := c.writeFrame(conn, msg); err != nil {
+			return err
+		}
+		if key := messageKey(msg); key != "" {
+			base := messageBase(msg)
+			acks = append(acks, ackMatcher(base.EventCode, base.Blockchain, key))
+		}
+	}
+	if err := c.awaitReplayed(conn, acks); err != nil {
+		return err
+	}
+	c.wmtx.Lock()
+	old := c.conn
+	c.conn = conn
+	c.wmtx.Unlock()
+	old.Close()
+	if c.ctx.Err() != nil {
+		// Close ran while we were reconnecting and may have missed conn
+		conn.Close()
+	}
+	return nil
+}
+
+// awaitReplayed reads conn until every replayed subscribe message is
+// acknowledged, so the acknowledgements don't surface as unmatched frames.
+// Subscriptions blocknative refuses to restore are told why, and events
+// arriving in between are dispatched as usual
+func (c *Client) awaitReplayed(conn *websocket.Conn, acks []func(*frameHeader) bool) error {
+	deadline := time.Now().Add(ackTimeout)
+	for len(acks) > 0 {
+		conn.SetReadDeadline(deadline)
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		c.opts.Recorder.Record(Inbound, data)
+		var hdr frameHeader
+		if err := json.Unmarshal(data, &hdr); err != nil {
+			c.deliverUnmatched(data)
+			continue
+		}
+		i := 0
+		for i < len(acks) && !acks[i](&hdr) {
+			i++
+		}
+		if i == len(acks) {
+			c.dispatch(data)
+			continue
+		}
+		acks = append(acks[:i], acks[i+1:]...)
+		if hdr.failed() {
+			if sub := c.registry.find(c.frameChain(&hdr), hdr.keys()...); sub != nil {
+				sub.fail(fmt.Errorf("failed to restore subscription: %w", serverError(&hdr)))
+			}
+		}
+	}
+	conn.SetReadDeadline(time.Time{})
+	return nil
+}
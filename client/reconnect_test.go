@@ -0,0 +1,124 @@
+package client
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ATMackay/go-blocknative/client/blocknativetest"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	b := Backoff{InitialInterval: 100 * time.Millisecond, MaxInterval: time.Second, Multiplier: 2, Jitter: 0.5}
+	for n, want := range []time.Duration{100, 200, 400, 800, 1000, 1000} {
+		want *= time.Millisecond
+		for i := 0; i < 20; i++ {
+			d := b.Delay(n)
+			require.LessOrEqual(t, d, want)
+			require.GreaterOrEqual(t, d, want/2)
+		}
+	}
+}
+
+func TestReconnect(t *testing.T) {
+	const address = "0xfa6de2697d59e88ed7fc4dfe5a33dac43565ea41"
+	cl, srv := newFakeClient(t, Opts{Reconnect: true, Backoff: Backoff{InitialInterval: 10 * time.Millisecond}})
+
+	sub, err := cl.NewAddressSubscription(context.Background(), address)
+	require.NoError(t, err)
+	txSub, err := cl.NewTransactionSubscription(context.Background(), "0x02")
+	require.NoError(t, err)
+	txSub.Unsubscribe()
+	_, err = srv.WaitForMessage(time.Second, "activeTransaction", "unwatch", "0x02")
+	require.NoError(t, err)
+
+	srv.Disconnect()
+	_, err = srv.WaitFor(context.Background(), func(m blocknativetest.Message) bool {
+		return m.Conn == 2 && m.CategoryCode == "accountAddress"
+	})
+	require.NoError(t, err)
+
+	// the session was restored without the unsubscribed transaction
+	var replayed []string
+	for _, msg := range srv.Received() {
+		if msg.Conn == 2 {
+			replayed = append(replayed, msg.CategoryCode+"/"+msg.EventCode)
+		}
+	}
+	require.Equal(t, []string{"initialize/checkDappId", "accountAddress/watch"}, replayed)
+	require.ElementsMatch(t, []string{address}, srv.Watched())
+
+	require.NoError(t, srv.SendTx(blocknativetest.TxEvent{Transaction: map[string]interface{}{"hash": "0x01", "watchedAddress": address}}))
+	select {
+	case ev := <-sub.Events():
+		require.Equal(t, "0x01", ev.Event.Transaction.Hash)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for event")
+	}
+	// the acknowledgements of the replayed messages were consumed
+	require.Len(t, cl.Unmatched(), 0)
+}
+
+func TestReconnectStale(t *testing.T) {
+	var conns int32
+	cl := newTestClient(t, Opts{
+		Reconnect:    true,
+		Backoff:      Backoff{InitialInterval: time.Millisecond},
+		PingInterval: 20 * time.Millisecond,
+		PongTimeout:  50 * time.Millisecond,
+	}, func(conn *websocket.Conn) {
+		if atomic.AddInt32(&conns, 1) == 1 {
+			// the first connection goes half-open
+			conn.SetPingHandler(func(string) error { return nil })
+		}
+		acknowledge(conn)
+	})
+	sub, err := cl.NewAddressSubscription(context.Background(), "0xfa6de2697d59e88ed7fc4dfe5a33dac43565ea41")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&conns) == 2 }, 2*time.Second, time.Millisecond)
+
+	// the stale connection was replaced without bothering the subscriber
+	select {
+	case err := <-sub.Err():
+		t.Fatalf("restored connection reported %v", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+	require.Len(t, cl.Unmatched(), 0)
+}
+
+func TestCloseWhileReconnecting(t *testing.T) {
+	cl, srv := newFakeClient(t, Opts{Reconnect: true, PingInterval: -1, Backoff: Backoff{InitialInterval: time.Millisecond}})
+	_, err := cl.NewAddressSubscription(context.Background(), "0xfa6de2697d59e88ed7fc4dfe5a33dac43565ea41")
+	require.NoError(t, err)
+	srv.Disconnect()
+	closed := make(chan struct{})
+	go func() {
+		cl.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Close did not return while reconnecting")
+	}
+}
+
+func TestReconnectGivesUp(t *testing.T) {
+	cl, srv := newFakeClient(t, Opts{Reconnect: true, Backoff: Backoff{InitialInterval: time.Millisecond, MaxAttempts: 2}})
+	sub, err := cl.NewAddressSubscription(context.Background(), "0xfa6de2697d59e88ed7fc4dfe5a33dac43565ea41")
+	require.NoError(t, err)
+
+	srv.Refuse(true)
+	srv.Disconnect()
+	select {
+	case err := <-sub.Err():
+		require.Contains(t, err.Error(), "failed to reconnect after 2 attempts")
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for error")
+	}
+	_, ok := <-sub.Events()
+	require.False(t, ok)
+}
@@ -0,0 +1,63 @@
+package client
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// Direction tells whether a recorded frame was received or sent by the client
+type Direction string
+
+// Frame directions
+const (
+	Inbound  Direction = "in"
+	Outbound Direction = "out"
+)
+
+// RecordedFrame is a single line of a recording
+type RecordedFrame struct {
+	Time      time.Time       `json:"time"`
+	Direction Direction       `json:"direction"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// Recorder writes every frame sent and received by a Client to w as
+// newline delimited JSON, one RecordedFrame per line. Recordings can be
+// replayed with blocknativetest.NewReplayServer
+type Recorder struct {
+	mtx sync.Mutex
+	enc *json.Encoder
+	err error
+}
+
+// NewRecorder returns a Recorder writing to w
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{enc: json.NewEncoder(w)}
+}
+
+// Record appends a frame to the recording. Frames which are not valid
+// JSON are recorded as JSON strings
+func (r *Recorder) Record(dir Direction, data []byte) error {
+	if r == nil {
+		return nil
+	}
+	if !json.Valid(data) {
+		data, _ = json.Marshal(string(data))
+	}
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+	r.err = r.enc.Encode(RecordedFrame{Time: time.Now().UTC(), Direction: dir, Data: data})
+	return r.err
+}
+
+// Err returns the first error encountered while writing the recording
+func (r *Recorder) Err() error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return r.err
+}
@@ -0,0 +1,57 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ATMackay/go-blocknative/client/blocknativetest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordReplay(t *testing.T) {
+	const address = "0xfa6de2697d59e88ed7fc4dfe5a33dac43565ea41"
+	var recording bytes.Buffer
+	cl, srv := newFakeClient(t, Opts{Recorder: NewRecorder(&recording)})
+	sub, err := cl.NewAddressSubscription(context.Background(), address)
+	require.NoError(t, err)
+	_, err = srv.WaitForMessage(time.Second, "accountAddress", "watch", address)
+	require.NoError(t, err)
+	hashes := []string{"0x01", "0x02", "0x03"}
+	var want []string
+	for _, hash := range hashes {
+		require.NoError(t, srv.SendTx(blocknativetest.TxEvent{Transaction: map[string]interface{}{"hash": hash, "watchedAddress": address}}))
+		select {
+		case ev := <-sub.Events():
+			want = append(want, ev.Event.Transaction.Hash)
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for event")
+		}
+	}
+	require.Equal(t, hashes, want)
+	require.NoError(t, cl.Close())
+
+	replay, err := blocknativetest.NewReplayServer(bytes.NewReader(recording.Bytes()), 0)
+	require.NoError(t, err)
+	defer replay.Close()
+	rc, err := New(context.Background(), Opts{Scheme: "ws", Host: replay.Host()})
+	require.NoError(t, err)
+	defer rc.Close()
+	require.NoError(t, rc.Initialize(NewBaseMessageMainnet("test-key")))
+	sub, err = rc.NewAddressSubscription(context.Background(), address)
+	require.NoError(t, err)
+	var got []string
+	for range hashes {
+		select {
+		case ev := <-sub.Events():
+			got = append(got, ev.Event.Transaction.Hash)
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for replayed event")
+		}
+	}
+	require.Equal(t, want, got)
+	_, err = replay.WaitForMessage(time.Second, "accountAddress", "watch", address)
+	require.NoError(t, err)
+	require.NoError(t, cl.opts.Recorder.Err())
+}
@@ -0,0 +1,238 @@
+package client
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SubscriptionKind identifies what a subscription watches
+type SubscriptionKind string
+
+// Subscription kinds
+const (
+	KindAddress     SubscriptionKind = "address"
+	KindTransaction SubscriptionKind = "transaction"
+	KindConfig      SubscriptionKind = "config"
+)
+
+// SubscriptionInfo describes a registered subscription
+type SubscriptionInfo struct {
+	Subscription AnySubscription
+	Key          string
+	// System and Network are the blockchain watched by the subscription
+	System    string
+	Network   string
+	Kind      SubscriptionKind
+	CreatedAt time.Time
+	// Events is the number of events routed to the subscription
+	Events uint64
+	// LastEvent is when the last event was routed, zero if none was
+	LastEvent time.Time
+}
+
+// registry holds the subscriptions of a Client keyed by blockchain and registryKey
+type registry struct {
+	mtx     sync.RWMutex
+	entries map[string]*SubscriptionInfo
+	keys    map[AnySubscription]string // entry key of every registered subscription
+}
+
+func newRegistry() *registry {
+	return &registry{
+		entries: make(map[string]*SubscriptionInfo),
+		keys:    make(map[AnySubscription]string),
+	}
+}
+
+// registryKey normalizes the subscription keys of system. Ethereum
+// addresses and hashes are hex strings which blocknative may return in a
+// different case than we sent, while bitcoin's base58 addresses and txids
+// are compared as they are
+func registryKey(system, key string) string {
+	if system == "" || system == SystemEthereum {
+		return strings.ToLower(key)
+	}
+	return key
+}
+
+// routeOrder is the order in which the kinds registered for a key are
+// tried, so an address watch takes its events before a config scoped to it
+var routeOrder = []SubscriptionKind{KindAddress, KindTransaction, KindConfig}
+
+// entryKey identifies a subscription of kind to key on chain. The same
+// address or hash may be watched on several networks, and an address may
+// be watched while a config is scoped to it
+func entryKey(chain Blockchain, kind SubscriptionKind, key string) string {
+	system := chain.System
+	if system == "" {
+		system = SystemEthereum
+	}
+	return system + "/" + chain.Network + "/" + string(kind) + "/" + registryKey(system, key)
+}
+
+// match returns the entry registered for key on chain, trying each kind
+// in routeOrder. r.mtx must be held
+func (r *registry) match(chain Blockchain, key string) (*SubscriptionInfo, bool) {
+	for _, kind := range routeOrder {
+		if e, ok := r.entries[entryKey(chain, kind, key)]; ok {
+			return e, true
+		}
+	}
+	return nil, false
+}
+
+func newEntry(sub AnySubscription, chain Blockchain, kind SubscriptionKind) *SubscriptionInfo {
+	return &SubscriptionInfo{Subscription: sub, Key: sub.Key(), System: chain.System, Network: chain.Network, Kind: kind, CreatedAt: time.Now()}
+}
+
+// put registers sub on chain, returning the entry it replaced if any
+func (r *registry) put(sub AnySubscription, chain Blockchain, kind SubscriptionKind) *SubscriptionInfo {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	key := entryKey(chain, kind, sub.Key())
+	prev := r.entries[key]
+	if prev != nil {
+		delete(r.keys, prev.Subscription)
+	}
+	r.entries[key] = newEntry(sub, chain, kind)
+	r.keys[sub] = key
+	return prev
+}
+
+// add registers sub on chain unless another subscription is registered
+// for its key, reporting whether it did
+func (r *registry) add(sub AnySubscription, chain Blockchain, kind SubscriptionKind) bool {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	key := entryKey(chain, kind, sub.Key())
+	if _, ok := r.entries[key]; ok {
+		return false
+	}
+	r.entries[key] = newEntry(sub, chain, kind)
+	r.keys[sub] = key
+	return true
+}
+
+// restore undoes put, reinstating the entry sub replaced. It reports false
+// if sub was removed or replaced in the meantime, leaving the registry as is
+func (r *registry) restore(sub AnySubscription, prev *SubscriptionInfo) bool {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	key, ok := r.keys[sub]
+	if !ok {
+		return false
+	}
+	delete(r.keys, sub)
+	if prev == nil {
+		delete(r.entries, key)
+		return true
+	}
+	r.entries[key] = prev
+	r.keys[prev.Subscription] = key
+	return true
+}
+
+// has reports whether sub is registered
+func (r *registry) has(sub AnySubscription) bool {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+	_, ok := r.keys[sub]
+	return ok
+}
+
+// remove unregisters sub unless it has already been replaced, reporting
+// whether it was the current entry for its key
+func (r *registry) remove(sub AnySubscription) bool {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	key, ok := r.keys[sub]
+	if !ok {
+		return false
+	}
+	delete(r.keys, sub)
+	delete(r.entries, key)
+	return true
+}
+
+func (r *registry) get(chain Blockchain, key string) (SubscriptionInfo, bool) {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+	e, ok := r.match(chain, key)
+	if !ok {
+		return SubscriptionInfo{}, false
+	}
+	return *e, true
+}
+
+// lookup returns the first subscription on chain matching one of keys
+// and counts the event being routed to it
+func (r *registry) lookup(chain Blockchain, keys ...string) AnySubscription {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		if e, ok := r.match(chain, key); ok {
+			e.Events++
+			e.LastEvent = time.Now()
+			return e.Subscription
+		}
+	}
+	return nil
+}
+
+// find is like lookup but doesn't count an event
+func (r *registry) find(chain Blockchain, keys ...string) AnySubscription {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		if e, ok := r.match(chain, key); ok {
+			return e.Subscription
+		}
+	}
+	return nil
+}
+
+// snapshot returns the registered subscriptions ordered by creation time
+func (r *registry) snapshot() []SubscriptionInfo {
+	r.mtx.RLock()
+	out := make([]SubscriptionInfo, 0, len(r.entries))
+	for _, e := range r.entries {
+		out = append(out, *e)
+	}
+	r.mtx.RUnlock()
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].CreatedAt.Before(out[j].CreatedAt)
+	})
+	return out
+}
+
+// subscriptions returns the registered subscriptions
+func (r *registry) subscriptions() []AnySubscription {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+	out := make([]AnySubscription, 0, len(r.entries))
+	for _, e := range r.entries {
+		out = append(out, e.Subscription)
+	}
+	return out
+}
+
+// clear empties the registry, returning the subscriptions it held
+func (r *registry) clear() []AnySubscription {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	subs := make([]AnySubscription, 0, len(r.entries))
+	for _, e := range r.entries {
+		subs = append(subs, e.Subscription)
+	}
+	r.entries = make(map[string]*SubscriptionInfo)
+	r.keys = make(map[AnySubscription]string)
+	return subs
+}
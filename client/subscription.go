@@ -1,73 +1,174 @@
 package client
 
 import (
-	"fmt"
-	"time"
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
 
-	"github.com/gorilla/websocket"
+	"github.com/ethereum/go-ethereum/accounts/abi"
 )
 
-var subscriptionSleep = 5 * time.Second
+var (
+	// subscriptionBuffer is the number of events a subscription holds before
+	// further events are dropped, see ErrEventDropped
+	subscriptionBuffer = 256
+	// subscriptionErrBuffer is the number of errors a subscription holds,
+	// further errors are dropped until the consumer catches up
+	subscriptionErrBuffer = 16
+)
 
-// Subscription represents a stream of events. Implementations
-// carry a channel with which to store events returned by the subscription backend
-type Subscription interface {
-	Events() chan interface{}
-	Unsubscribe()
-	Err() chan error
-}
+var errSubscriptionClosed = errors.New("subscription closed")
 
-func eventLoop(cl *Client, sub Subscription, unsubMsg interface{}) {
-	e := sub.Events()
-	defer func() {
-		sub.Unsubscribe()
-		cl.WriteJSON(unsubMsg)
-		close(e)
-	}()
-	for {
-		select {
-		case <-sub.Err():
-			return
-		default:
-			var msg EthTxPayload
-			if err := cl.ReadJSON(&msg); err != nil {
-				if err := cl.ReadJSON(msg); err != nil {
-					if e, ok := err.(*websocket.CloseError); ok {
-						if e.Code != 1000 {
-							sub.Err() <- fmt.Errorf("websocket close error: %v", err)
-							return
-						}
-					}
-					break
-				} else {
-					break
-				}
-			}
-			e <- msg
-		}
-		time.Sleep(subscriptionSleep)
-	}
+// ErrEventDropped is reported on the Err channel of a subscription for every
+// event dropped because its consumer fell a full buffer behind. Events are
+// dropped rather than waited for so that one slow consumer can't stall the
+// read loop shared by every subscription and pending request of a Client
+var ErrEventDropped = errors.New("subscription buffer full, event dropped")
+
+// AnySubscription is implemented by every Subscription regardless of the
+// type of its events. It is the view of a subscription held by the Client
+type AnySubscription interface {
+	// Key returns the address, transaction hash or config scope being watched
+	Key() string
+	// Unsubscribe stops the subscription and waits for blocknative to acknowledge it
+	Unsubscribe() error
+	// UnsubscribeContext is like Unsubscribe, bounding the wait by ctx
+	UnsubscribeContext(ctx context.Context) error
+
+	// deliver decodes a frame routed to the subscription and emits it
+	deliver(ctx context.Context, data []byte)
+	// fail reports err to the subscriber without blocking
+	fail(err error)
+	close()
+	closed() <-chan struct{}
+	setUnsubscribe(f func(ctx context.Context) error)
 }
 
-type subscription struct {
-	key       string // address or txHash
-	eventChan chan interface{}
+// Subscription represents a stream of events of type T. Frames routed
+// to the subscription are decoded into T and delivered on Events,
+// decoding and connection errors are delivered on Err
+type Subscription[T any] struct {
+	key       string   // address, txHash or config scope
+	contract  *abi.ABI // decodes contract calls of config subscriptions
+	eventChan chan T
 	errChan   chan error
+
+	mtx       sync.Mutex // held while delivering so the event channel is not closed underneath a send
+	isClosed  bool
+	done      chan struct{}
+	closeOnce sync.Once
+	unsubOnce sync.Once
+	unsubErr  error
+	// unsubscribe notifies the Client (and blocknative servers) that the subscription
+	// is no longer wanted. It is set by the Client when the subscription is registered
+	unsubscribe func(ctx context.Context) error
 }
 
 // NewSubscription creates a carrier for tracking events
-func NewSubscription(key string) *subscription {
-	return &subscription{key: key, eventChan: make(chan interface{}), errChan: make(chan error, 1)}
+func NewSubscription[T any](key string) *Subscription[T] {
+	return &Subscription[T]{
+		key:       key,
+		eventChan: make(chan T, subscriptionBuffer),
+		errChan:   make(chan error, subscriptionErrBuffer),
+		done:      make(chan struct{}),
+	}
 }
 
-func (a *subscription) Events() chan interface{} {
-	return a.eventChan
+// Key returns the address, transaction hash or config scope being watched
+func (a *Subscription[T]) Key() string {
+	return a.key
 }
 
-func (a *subscription) Unsubscribe() {
-	a.errChan <- fmt.Errorf("subscription closed")
+// Events returns the event stream, which is closed once the subscription ends.
+// Events arriving while its buffer is full are dropped, see ErrEventDropped
+func (a *Subscription[T]) Events() <-chan T {
+	return a.eventChan
 }
 
-func (a *subscription) Err() chan error {
+// Err returns the error stream of the subscription
+func (a *Subscription[T]) Err() <-chan error {
 	return a.errChan
 }
+
+// Unsubscribe stops the subscription, notifying blocknative servers and closing the event channel.
+// It returns the error reported by blocknative, the subscription is closed regardless
+func (a *Subscription[T]) Unsubscribe() error {
+	return a.UnsubscribeContext(context.Background())
+}
+
+// UnsubscribeContext is like Unsubscribe but gives up waiting for blocknative's
+// acknowledgement once ctx is done. Only the first call notifies blocknative,
+// later calls return its result
+func (a *Subscription[T]) UnsubscribeContext(ctx context.Context) error {
+	a.unsubOnce.Do(func() {
+		if a.unsubscribe != nil {
+			a.unsubErr = a.unsubscribe(ctx)
+		}
+		a.fail(errSubscriptionClosed)
+		a.close()
+	})
+	return a.unsubErr
+}
+
+func (a *Subscription[T]) deliver(ctx context.Context, data []byte) {
+	if ev, ok := a.decode(data); ok {
+		a.emit(ev)
+	}
+}
+
+// decode decodes an event for a, along with its contract call when a has
+// an ABI. Errors are reported on a, ok is false if there is no event to emit
+func (a *Subscription[T]) decode(data []byte) (ev T, ok bool) {
+	if err := json.Unmarshal(data, &ev); err != nil {
+		a.fail(err)
+		return ev, false
+	}
+	if d, ok := any(&ev).(callDecoder); ok && a.contract != nil {
+		// the event is still delivered when its input doesn't match the ABI
+		if err := d.decodeCall(a.contract); err != nil {
+			a.fail(err)
+		}
+	}
+	return ev, true
+}
+
+// emit delivers a decoded event without blocking. If the consumer has not
+// made room in the buffer the event is dropped and ErrEventDropped reported
+func (a *Subscription[T]) emit(ev T) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	if a.isClosed {
+		return
+	}
+	select {
+	case a.eventChan <- ev:
+	default:
+		a.fail(ErrEventDropped)
+	}
+}
+
+func (a *Subscription[T]) fail(err error) {
+	select {
+	case a.errChan <- err:
+	default:
+	}
+}
+
+func (a *Subscription[T]) close() {
+	a.closeOnce.Do(func() {
+		close(a.done)
+		a.mtx.Lock()
+		a.isClosed = true
+		close(a.eventChan)
+		a.mtx.Unlock()
+	})
+}
+
+func (a *Subscription[T]) closed() <-chan struct{} {
+	return a.done
+}
+
+func (a *Subscription[T]) setUnsubscribe(f func(ctx context.Context) error) {
+	a.unsubscribe = f
+}
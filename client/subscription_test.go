@@ -0,0 +1,301 @@
+package client
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ATMackay/go-blocknative/client/blocknativetest"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscriptionContext(t *testing.T) {
+	const address = "0xfa6de2697d59e88ed7fc4dfe5a33dac43565ea41"
+	cl, srv := newFakeClient(t, Opts{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sub, err := cl.NewAddressSubscription(ctx, address)
+	require.NoError(t, err)
+	_, err = srv.WaitForMessage(time.Second, "accountAddress", "watch", address)
+	require.NoError(t, err)
+
+	// events are delivered as fast as they arrive
+	for i := 0; i < 10; i++ {
+		require.NoError(t, srv.SendTx(blocknativetest.TxEvent{Transaction: map[string]interface{}{"hash": "0x01", "watchedAddress": address}}))
+	}
+	for i := 0; i < 10; i++ {
+		select {
+		case <-sub.Events():
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for event")
+		}
+	}
+	cancel()
+
+	_, err = srv.WaitForMessage(time.Second, "accountAddress", "unwatch", address)
+	require.NoError(t, err)
+	_, ok := <-sub.Events()
+	require.False(t, ok)
+	require.Empty(t, cl.Subscriptions())
+	require.Empty(t, srv.Watched())
+}
+
+func TestSubscribeTyped(t *testing.T) {
+	const txHash = "0x01"
+	type txEvent struct {
+		Event struct {
+			Transaction struct {
+				Hash  string `json:"hash"`
+				Nonce int    `json:"nonce"`
+			} `json:"transaction"`
+		} `json:"event"`
+	}
+	cl := newTestClient(t, Opts{}, func(conn *websocket.Conn) {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			return
+		}
+		conn.WriteJSON(map[string]interface{}{"status": "ok", "event": map[string]interface{}{"eventCode": "txSent", "transaction": map[string]string{"hash": txHash}}})
+		conn.WriteJSON(map[string]interface{}{"event": map[string]interface{}{"transaction": map[string]string{"hash": txHash, "nonce": "NaN"}}})
+		conn.WriteJSON(map[string]interface{}{"event": map[string]interface{}{"transaction": map[string]string{"hash": txHash}}})
+		conn.ReadMessage()
+	})
+	sub, err := SubscribeTransaction[txEvent](context.Background(), cl, txHash)
+	require.NoError(t, err)
+	select {
+	case ev := <-sub.Events():
+		require.Equal(t, txHash, ev.Event.Transaction.Hash)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for event")
+	}
+	// the frame which could not be decoded into txEvent was reported as an error
+	select {
+	case err := <-sub.Err():
+		require.Error(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for error")
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	const address = "0xFA6DE2697D59E88ED7FC4DFE5A33DAC43565EA41"
+	cl, srv := newFakeClient(t, Opts{})
+	addrSub, err := cl.NewAddressSubscription(context.Background(), address)
+	require.NoError(t, err)
+	_, err = cl.NewTransactionSubscription(context.Background(), "0x01")
+	require.NoError(t, err)
+	_, err = srv.WaitForMessage(time.Second, "activeTransaction", "txSent", "0x01")
+	require.NoError(t, err)
+
+	// keys are case insensitive
+	require.True(t, cl.HasSubscription(strings.ToLower(address)))
+	sub, ok := cl.Subscription(address)
+	require.True(t, ok)
+	require.Equal(t, AnySubscription(addrSub), sub)
+	require.False(t, cl.HasSubscription("0x02"))
+
+	require.NoError(t, srv.SendTx(blocknativetest.TxEvent{Transaction: map[string]interface{}{"hash": "0x03", "watchedAddress": address}}))
+	select {
+	case <-addrSub.Events():
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for event")
+	}
+	subs := cl.Subscriptions()
+	require.Len(t, subs, 2)
+	require.Equal(t, address, subs[0].Key)
+	require.Equal(t, KindAddress, subs[0].Kind)
+	require.Equal(t, uint64(1), subs[0].Events)
+	require.False(t, subs[0].LastEvent.IsZero())
+	require.Equal(t, KindTransaction, subs[1].Kind)
+	require.Zero(t, subs[1].Events)
+	require.False(t, subs[1].CreatedAt.Before(subs[0].CreatedAt))
+
+	require.NoError(t, cl.KillSubscription(context.Background(), address))
+	require.ErrorIs(t, cl.KillSubscription(context.Background(), address), ErrUnknownSubscription)
+	require.False(t, cl.HasSubscription(address))
+	require.Len(t, cl.Subscriptions(), 1)
+}
+
+func TestMultiNetwork(t *testing.T) {
+	const address = "0xfa6de2697d59e88ed7fc4dfe5a33dac43565ea41"
+	cl, srv := newFakeClient(t, Opts{})
+	mainSub, err := cl.NewAddressSubscription(context.Background(), address)
+	require.NoError(t, err)
+	polygonSub, err := cl.NewAddressSubscription(context.Background(), address, OnChain(137))
+	require.NoError(t, err)
+	_, err = cl.NewAddressSubscription(context.Background(), address, OnChain(-1))
+	require.Error(t, err)
+
+	require.True(t, cl.HasSubscription(address))
+	info, ok := cl.SubscriptionInfo(address, OnNetwork(Blockchain{System: "ethereum", Network: "matic-main"}))
+	require.True(t, ok)
+	require.Equal(t, "matic-main", info.Network)
+	require.Len(t, cl.Subscriptions(), 2)
+
+	for _, tc := range []struct {
+		network string
+		sub     *Subscription[EthTxPayload]
+	}{{"matic-main", polygonSub}, {"main", mainSub}} {
+		require.NoError(t, srv.SendTx(blocknativetest.TxEvent{
+			Blockchain:  blocknativetest.Blockchain{System: "ethereum", Network: tc.network},
+			Transaction: map[string]interface{}{"hash": "0x01", "watchedAddress": address},
+		}))
+		select {
+		case ev := <-tc.sub.Events():
+			require.Equal(t, tc.network, ev.Event.Network)
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for %s event", tc.network)
+		}
+	}
+	require.Len(t, mainSub.Events(), 0)
+	require.Len(t, polygonSub.Events(), 0)
+
+	// unsubscribing one network leaves the other watched
+	require.NoError(t, cl.KillSubscription(context.Background(), address, OnChain(137)))
+	require.True(t, cl.HasSubscription(address))
+	require.False(t, cl.HasSubscription(address, OnChain(137)))
+	received := srv.Received()
+	unwatch := received[len(received)-1]
+	require.Equal(t, "unwatch", unwatch.EventCode)
+	require.Equal(t, "matic-main", unwatch.Blockchain.Network)
+}
+
+func TestUnsubscribeReplaced(t *testing.T) {
+	const address = "0xfa6de2697d59e88ed7fc4dfe5a33dac43565ea41"
+	cl, srv := newFakeClient(t, Opts{})
+	old, err := cl.NewAddressSubscription(context.Background(), address)
+	require.NoError(t, err)
+	replacement, err := cl.NewAddressSubscription(context.Background(), address)
+	require.NoError(t, err)
+	_, ok := <-old.Events()
+	require.False(t, ok)
+
+	// unsubscribing the replaced subscription leaves the replacement watched
+	require.NoError(t, old.Unsubscribe())
+	sub, ok := cl.Subscription(address)
+	require.True(t, ok)
+	require.Equal(t, AnySubscription(replacement), sub)
+	require.Equal(t, 1, cl.history.Len())
+	require.Equal(t, []string{address}, srv.Watched())
+	for _, msg := range srv.Received() {
+		require.NotEqual(t, "unwatch", msg.EventCode)
+	}
+}
+
+func TestAddressAndConfig(t *testing.T) {
+	const address = "0xfa6de2697d59e88ed7fc4dfe5a33dac43565ea41"
+	cl, srv := newFakeClient(t, Opts{})
+	sub, err := cl.NewAddressSubscription(context.Background(), address)
+	require.NoError(t, err)
+	cfg, err := cl.NewEventSubscription(context.Background(), NewConfiguration(NewBaseMessageMainnet("test-key"), NewConfig(address, true, nil)))
+	require.NoError(t, err)
+
+	// the config scoped to the address doesn't replace the address watch
+	require.Len(t, cl.Subscriptions(), 2)
+	require.Equal(t, 2, cl.history.Len())
+	found, ok := cl.Subscription(address)
+	require.True(t, ok)
+	require.Equal(t, AnySubscription(sub), found)
+	require.NoError(t, srv.SendTx(blocknativetest.TxEvent{Transaction: map[string]interface{}{"hash": "0x01", "watchedAddress": address}}))
+	select {
+	case ev, ok := <-sub.Events():
+		require.True(t, ok)
+		require.Equal(t, "0x01", ev.Event.Transaction.Hash)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for event")
+	}
+
+	// unsubscribing one leaves the other
+	require.NoError(t, sub.Unsubscribe())
+	found, ok = cl.Subscription(address)
+	require.True(t, ok)
+	require.Equal(t, AnySubscription(cfg), found)
+	require.Equal(t, 1, cl.history.Len())
+}
+
+func TestUnsubscribeBeforeAck(t *testing.T) {
+	const address = "0xfa6de2697d59e88ed7fc4dfe5a33dac43565ea41"
+	watched, release := make(chan struct{}), make(chan struct{})
+	var unwatched int32
+	cl := newTestClient(t, Opts{}, func(conn *websocket.Conn) {
+		for {
+			var msg map[string]interface{}
+			if err := conn.ReadJSON(&msg); err != nil {
+				return
+			}
+			switch msg["eventCode"] {
+			case "watch":
+				// hold the acknowledgement back
+				close(watched)
+				<-release
+			case "unwatch":
+				atomic.AddInt32(&unwatched, 1)
+			}
+			if err := conn.WriteJSON(map[string]interface{}{"status": "ok", "event": msg}); err != nil {
+				return
+			}
+		}
+	})
+	require.NoError(t, cl.Initialize(NewBaseMessageMainnet("test-key")))
+
+	type result struct {
+		sub *Subscription[EthTxPayload]
+		err error
+	}
+	subscribed := make(chan result, 1)
+	go func() {
+		sub, err := cl.NewAddressSubscription(context.Background(), address)
+		subscribed <- result{sub, err}
+	}()
+	<-watched
+	killed := make(chan error, 1)
+	go func() { killed <- cl.KillSubscription(context.Background(), address) }()
+	require.Eventually(t, func() bool { return !cl.HasSubscription(address) }, time.Second, time.Millisecond)
+	close(release)
+
+	require.NoError(t, <-killed)
+	res := <-subscribed
+	require.NoError(t, res.err)
+	_, ok := <-res.sub.Events()
+	require.False(t, ok)
+	// the subscription was unwatched and isn't restored after a reconnect
+	require.Equal(t, int32(1), atomic.LoadInt32(&unwatched))
+	require.Empty(t, cl.Subscriptions())
+	require.Equal(t, 0, cl.history.Len())
+}
+
+func TestSlowConsumer(t *testing.T) {
+	const slow, fast = "0xfa6de2697d59e88ed7fc4dfe5a33dac43565ea41", "0xdac17f958d2ee523a2206206994597c13d831ec7"
+	cl, srv := newFakeClient(t, Opts{})
+	slowSub, err := cl.NewAddressSubscription(context.Background(), slow)
+	require.NoError(t, err)
+	fastSub, err := cl.NewAddressSubscription(context.Background(), fast)
+	require.NoError(t, err)
+
+	// nobody reads slowSub, which overflows
+	for i := 0; i < subscriptionBuffer+1; i++ {
+		require.NoError(t, srv.SendTx(blocknativetest.TxEvent{Transaction: map[string]interface{}{"hash": "0x01", "watchedAddress": slow}}))
+	}
+	require.NoError(t, srv.SendTx(blocknativetest.TxEvent{Transaction: map[string]interface{}{"hash": "0x02", "watchedAddress": fast}}))
+	select {
+	case ev := <-fastSub.Events():
+		require.Equal(t, "0x02", ev.Event.Transaction.Hash)
+	case <-time.After(time.Second):
+		t.Fatal("a slow consumer stalled the other subscriptions")
+	}
+	select {
+	case err := <-slowSub.Err():
+		require.ErrorIs(t, err, ErrEventDropped)
+	case <-time.After(time.Second):
+		t.Fatal("dropped event was not reported")
+	}
+	require.Len(t, slowSub.Events(), subscriptionBuffer)
+
+	// acknowledgements still get through
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err = cl.NewTransactionSubscription(ctx, "0x03")
+	require.NoError(t, err)
+}
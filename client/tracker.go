@@ -0,0 +1,426 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrTrackerClosed is returned by TxTracker.Wait for transactions which
+	// stopped being followed before they settled
+	ErrTrackerClosed = errors.New("tx tracker closed")
+	// ErrAlreadySubscribed is returned by TxTracker for hashes the client
+	// already holds a subscription for, which the tracker won't replace
+	ErrAlreadySubscribed = errors.New("already subscribed")
+)
+
+// SettledTxRetention is how long a TxTracker keeps settled transactions, so
+// that Wait and State still report them, before forgetting them
+var SettledTxRetention = 10 * time.Minute
+
+// TxState is a state of a transaction followed by a TxTracker. A transaction
+// moves from sent to pending, possibly through speedup or cancel, to one of
+// the final states confirmed, failed or dropped
+type TxState int
+
+// Transaction states
+const (
+	TxSent TxState = iota
+	TxPending
+	TxSpeedUp
+	TxCancel
+	TxConfirmed
+	TxFailed
+	TxDropped
+)
+
+var txStateNames = [...]string{"sent", "pending", "speedup", "cancel", "confirmed", "failed", "dropped"}
+
+func (s TxState) String() string {
+	if s < 0 || int(s) >= len(txStateNames) {
+		return "unknown"
+	}
+	return txStateNames[s]
+}
+
+// Final reports whether s is confirmed, failed or dropped
+func (s TxState) Final() bool {
+	return s >= TxConfirmed
+}
+
+// rank orders states so that late or repeated events can't move a
+// transaction backwards
+func (s TxState) rank() int {
+	switch s {
+	case TxSpeedUp, TxCancel:
+		return int(TxSpeedUp)
+	case TxConfirmed, TxFailed, TxDropped:
+		return int(TxConfirmed)
+	}
+	return int(s)
+}
+
+// txStates maps event codes to the state they move a transaction to
+var txStates = map[EventCode]TxState{
+	EventTxSent:           TxSent,
+	EventTxPool:           TxPending,
+	EventTxPoolSimulation: TxPending,
+	EventTxStuck:          TxPending,
+	EventTxSpeedUp:        TxSpeedUp,
+	EventTxCancel:         TxCancel,
+	EventTxConfirmed:      TxConfirmed,
+	EventTxFailed:         TxFailed,
+	EventTxDropped:        TxDropped,
+}
+
+// TxOutcome is how a followed transaction settled
+type TxOutcome struct {
+	// Hash is the hash the transaction was tracked by
+	Hash string
+	// FinalHash is the hash of the transaction which settled, which differs
+	// from Hash if the transaction was sped up or cancelled
+	FinalHash string
+	// Replacements lists the hashes which replaced Hash, in order
+	Replacements []string
+	State        TxState
+	// Cancelled is set when the settled transaction is a cancellation
+	Cancelled   bool
+	BlockNumber int
+	BlockHash   string
+	GasUsed     BigInt
+	// Event is the event which settled the transaction
+	Event EthTxPayload
+}
+
+// TxTracker follows transactions through their lifecycle. Speed-ups and
+// cancellations are followed automatically by subscribing to the hash of
+// the replacement, so Wait returns the outcome of whichever transaction of
+// the chain settles. Settled transactions are forgotten after
+// SettledTxRetention. The tracker doesn't replace subscriptions the client
+// already holds: tracking such a hash fails with ErrAlreadySubscribed, while
+// subscribing to a followed hash elsewhere stops it being followed
+type TxTracker struct {
+	c      *Client
+	ctx    context.Context
+	cancel context.CancelFunc
+	opts   []SubscribeOption
+
+	mtx    sync.Mutex
+	txs    map[string]*trackedTx // by every hash of the chain
+	closed bool
+}
+
+// trackedTx is a transaction and the replacements it was followed through
+type trackedTx struct {
+	hashes    []string // original hash first
+	state     TxState
+	cancelled bool
+	subs      []*Subscription[EthTxPayload]
+	following sync.WaitGroup // hashes being subscribed to
+	lastErr   error
+	outcome   *TxOutcome
+	err       error
+	done      chan struct{} // closed once settled and unsubscribed
+	doneOnce  sync.Once
+	expiry    *time.Timer // forgets the settled tx
+}
+
+// NewTxTracker returns a tracker following transactions through c, on the
+// network chosen by opts
+func (c *Client) NewTxTracker(opts ...SubscribeOption) *TxTracker {
+	ctx, cancel := context.WithCancel(c.ctx)
+	return &TxTracker{
+		c:      c,
+		ctx:    ctx,
+		cancel: cancel,
+		opts:   opts,
+		txs:    make(map[string]*trackedTx),
+	}
+}
+
+// Track starts following hash. ctx bounds the wait for blocknative to
+// accept the subscription, the transaction is followed until it settles or
+// the tracker is closed. Tracking a hash already followed does nothing
+func (t *TxTracker) Track(ctx context.Context, hash string) error {
+	_, err := t.track(ctx, hash)
+	return err
+}
+
+func (t *TxTracker) track(ctx context.Context, hash string) (*trackedTx, error) {
+	if t.ctx.Err() != nil {
+		return nil, ErrTrackerClosed
+	}
+	t.mtx.Lock()
+	if tx, ok := t.txs[registryKey(SystemEthereum, hash)]; ok {
+		t.mtx.Unlock()
+		return tx, nil
+	}
+	tx := &trackedTx{hashes: []string{hash}, done: make(chan struct{})}
+	t.txs[registryKey(SystemEthereum, hash)] = tx
+	t.mtx.Unlock()
+	if err := t.follow(ctx, tx, hash); err != nil {
+		t.mtx.Lock()
+		delete(t.txs, registryKey(SystemEthereum, hash))
+		t.mtx.Unlock()
+		return nil, err
+	}
+	return tx, nil
+}
+
+// Wait tracks hash if it isn't yet and waits until it, or a transaction
+// replacing it, settles
+func (t *TxTracker) Wait(ctx context.Context, hash string) (*TxOutcome, error) {
+	tx, err := t.track(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	select {
+	case <-tx.done:
+		return tx.outcome, tx.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// State returns the state of the tracked transaction with the given hash,
+// which may also be the hash of one of its replacements
+func (t *TxTracker) State(hash string) (TxState, bool) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	tx, ok := t.txs[registryKey(SystemEthereum, hash)]
+	if !ok {
+		return 0, false
+	}
+	return tx.state, true
+}
+
+// Forget stops following the transaction with the given hash and drops
+// it from the tracker
+func (t *TxTracker) Forget(hash string) {
+	t.mtx.Lock()
+	tx, ok := t.txs[registryKey(SystemEthereum, hash)]
+	if ok {
+		for _, h := range tx.hashes {
+			delete(t.txs, registryKey(SystemEthereum, h))
+		}
+		t.settle(tx, nil, ErrTrackerClosed)
+	}
+	t.mtx.Unlock()
+	if ok {
+		t.finish(t.c.ctx, tx)
+	}
+}
+
+// Close stops following every transaction, waiting calls return
+// ErrTrackerClosed. Every hash followed is unsubscribed before it returns
+func (t *TxTracker) Close() {
+	t.cancel()
+	t.mtx.Lock()
+	t.closed = true
+	txs := make(map[*trackedTx]bool)
+	for _, tx := range t.txs {
+		txs[tx] = true
+	}
+	t.txs = make(map[string]*trackedTx)
+	for tx := range txs {
+		t.settle(tx, nil, ErrTrackerClosed)
+		tx.expiry.Stop()
+	}
+	t.mtx.Unlock()
+	for tx := range txs {
+		t.finish(t.c.ctx, tx)
+	}
+}
+
+// follow subscribes to hash as part of tx
+func (t *TxTracker) follow(ctx context.Context, tx *trackedTx, hash string) error {
+	t.mtx.Lock()
+	if t.closed || tx.settled() {
+		t.mtx.Unlock()
+		return ErrTrackerClosed
+	}
+	// finish waits for the subscription to be added before releasing tx
+	tx.following.Add(1)
+	t.mtx.Unlock()
+	defer tx.following.Done()
+
+	sub, err := t.c.subscribeTracked(ctx, t.ctx, hash, t.opts)
+	if err != nil {
+		return err
+	}
+	t.mtx.Lock()
+	tx.subs = append(tx.subs, sub)
+	t.mtx.Unlock()
+	go t.run(tx, hash, sub)
+	return nil
+}
+
+// run handles the events of a single hash of tx
+func (t *TxTracker) run(tx *trackedTx, hash string, sub *Subscription[EthTxPayload]) {
+	for {
+		select {
+		case ev, ok := <-sub.Events():
+			if !ok {
+				t.mtx.Lock()
+				err := tx.lastErr
+				if err == nil {
+					err = ErrTrackerClosed
+				}
+				if strings.EqualFold(tx.hashes[len(tx.hashes)-1], hash) {
+					// the hash being followed is gone, so the outcome can't be known
+					t.settle(tx, nil, err)
+				}
+				t.mtx.Unlock()
+				t.finish(t.ctx, tx)
+				return
+			}
+			t.handle(tx, hash, ev)
+		case err := <-sub.Err():
+			if err != errSubscriptionClosed {
+				t.mtx.Lock()
+				tx.lastErr = err
+				t.mtx.Unlock()
+			}
+		}
+	}
+}
+
+// handle moves tx to the state of ev, which was received for hash
+func (t *TxTracker) handle(tx *trackedTx, hash string, ev EthTxPayload) {
+	state, ok := txStates[ev.Event.EventCode]
+	if !ok {
+		return
+	}
+	t.mtx.Lock()
+	if tx.settled() || state.rank() < tx.state.rank() {
+		t.mtx.Unlock()
+		return
+	}
+	current := tx.hashes[len(tx.hashes)-1]
+	var next string
+	if state == TxSpeedUp || state == TxCancel {
+		for _, h := range []string{ev.Event.Transaction.ReplaceHash, ev.Event.Transaction.Hash} {
+			if h != "" && !tx.has(h) {
+				next = h
+			}
+		}
+	} else if !strings.EqualFold(hash, current) {
+		// only the replacement can settle a replaced transaction
+		t.mtx.Unlock()
+		return
+	}
+	tx.state = state
+	if next != "" {
+		// a sped up cancellation still cancels
+		tx.cancelled = tx.cancelled || state == TxCancel
+		tx.hashes = append(tx.hashes, next)
+		t.txs[registryKey(SystemEthereum, next)] = tx
+	}
+	if state.Final() {
+		t.settle(tx, &ev, nil)
+	}
+	t.mtx.Unlock()
+
+	if state.Final() {
+		t.finish(t.ctx, tx)
+	}
+	if next != "" {
+		if err := t.follow(t.ctx, tx, next); err != nil {
+			t.mtx.Lock()
+			t.settle(tx, nil, err)
+			t.mtx.Unlock()
+			t.finish(t.ctx, tx)
+		}
+	}
+}
+
+// settle records the outcome of tx and schedules forgetting it, t.mtx must
+// be held. Waiting calls return once finish has released tx
+func (t *TxTracker) settle(tx *trackedTx, ev *EthTxPayload, err error) {
+	if tx.settled() {
+		return
+	}
+	tx.expiry = time.AfterFunc(SettledTxRetention, func() { t.expire(tx) })
+	if err != nil {
+		tx.err = err
+		return
+	}
+	out := &TxOutcome{
+		Hash:         tx.hashes[0],
+		FinalHash:    tx.hashes[len(tx.hashes)-1],
+		Replacements: append([]string(nil), tx.hashes[1:]...),
+		State:        tx.state,
+		Cancelled:    tx.cancelled,
+		BlockNumber:  ev.Event.Transaction.BlockNumber,
+		BlockHash:    ev.Event.Transaction.BlockHash,
+		GasUsed:      ev.Event.Transaction.GasUsed,
+		Event:        *ev,
+	}
+	if h := ev.Event.Transaction.Hash; h != "" {
+		out.FinalHash = h
+	}
+	tx.outcome = out
+}
+
+// finish unsubscribes every hash of a settled tx, waiting for the
+// acknowledgements on ctx, before waking the callers waiting for it
+func (t *TxTracker) finish(ctx context.Context, tx *trackedTx) {
+	t.mtx.Lock()
+	settled := tx.settled()
+	t.mtx.Unlock()
+	if !settled {
+		return
+	}
+	tx.following.Wait()
+	t.mtx.Lock()
+	subs := append([]*Subscription[EthTxPayload](nil), tx.subs...)
+	t.mtx.Unlock()
+	for _, sub := range subs {
+		// blocks until an unsubscribe already under way has finished
+		sub.UnsubscribeContext(ctx)
+	}
+	tx.doneOnce.Do(func() { close(tx.done) })
+}
+
+// expire forgets the hashes of a settled tx which still refer to it
+func (t *TxTracker) expire(tx *trackedTx) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	for _, h := range tx.hashes {
+		if t.txs[registryKey(SystemEthereum, h)] == tx {
+			delete(t.txs, registryKey(SystemEthereum, h))
+		}
+	}
+}
+
+func (tx *trackedTx) settled() bool {
+	return tx.outcome != nil || tx.err != nil
+}
+
+func (tx *trackedTx) has(hash string) bool {
+	for _, h := range tx.hashes {
+		if strings.EqualFold(h, hash) {
+			return true
+		}
+	}
+	return false
+}
+
+// subscribeTracked subscribes to hash, waiting for blocknative's
+// acknowledgement on ctx and watching until life is done. It fails with
+// ErrAlreadySubscribed if another subscription watches hash
+func (c *Client) subscribeTracked(ctx, life context.Context, hash string, opts []SubscribeOption) (*Subscription[EthTxPayload], error) {
+	base, err := c.base(opts)
+	if err != nil {
+		return nil, err
+	}
+	sub := NewSubscription[EthTxPayload](hash)
+	if err := c.confirmNew(ctx, sub, NewTxSubscribe(base, hash), NewTxUnsubscribe(base, hash)); err != nil {
+		return nil, err
+	}
+	go c.unsubscribeWhenDone(life, sub)
+	return sub, nil
+}
@@ -0,0 +1,150 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ATMackay/go-blocknative/client/blocknativetest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTxTracker(t *testing.T) {
+	const (
+		original = "0x01"
+		speedUp  = "0x02"
+		cancel   = "0x03"
+	)
+	cl, srv := newFakeClient(t, Opts{})
+	tracker := cl.NewTxTracker()
+	defer tracker.Close()
+	require.NoError(t, tracker.Track(context.Background(), original))
+	state, ok := tracker.State(original)
+	require.True(t, ok)
+	require.Equal(t, TxSent, state)
+
+	send := func(code EventCode, tx map[string]interface{}) {
+		t.Helper()
+		require.NoError(t, srv.SendTx(blocknativetest.TxEvent{EventCode: code.String(), Transaction: tx}))
+	}
+	waitState := func(hash string, want TxState) {
+		t.Helper()
+		require.Eventually(t, func() bool {
+			state, _ := tracker.State(hash)
+			return state == want
+		}, time.Second, time.Millisecond)
+	}
+	send(EventTxPool, map[string]interface{}{"hash": original, "status": "pending"})
+	waitState(original, TxPending)
+
+	// the replacements are followed
+	send(EventTxSpeedUp, map[string]interface{}{"hash": original, "replaceHash": speedUp, "status": "speedup"})
+	_, err := srv.WaitForMessage(time.Second, "activeTransaction", "txSent", speedUp)
+	require.NoError(t, err)
+	waitState(speedUp, TxSpeedUp)
+	send(EventTxCancel, map[string]interface{}{"hash": speedUp, "replaceHash": cancel, "status": "cancel"})
+	_, err = srv.WaitForMessage(time.Second, "activeTransaction", "txSent", cancel)
+	require.NoError(t, err)
+
+	// late events of replaced transactions don't settle the chain
+	send(EventTxDropped, map[string]interface{}{"hash": original, "status": "dropped"})
+	send(EventTxPool, map[string]interface{}{"hash": cancel, "status": "pending"})
+	send(EventTxConfirmed, map[string]interface{}{"hash": cancel, "status": "confirmed", "blockNumber": 17, "blockHash": "0xbb", "gasUsed": "21000"})
+
+	ctx, cancelWait := context.WithTimeout(context.Background(), time.Second)
+	defer cancelWait()
+	out, err := tracker.Wait(ctx, original)
+	require.NoError(t, err)
+	// every hash of the chain is unsubscribed before Wait returns
+	require.Empty(t, cl.Subscriptions())
+	require.Equal(t, TxConfirmed, out.State)
+	require.Equal(t, original, out.Hash)
+	require.Equal(t, cancel, out.FinalHash)
+	require.Equal(t, []string{speedUp, cancel}, out.Replacements)
+	require.True(t, out.Cancelled)
+	require.Equal(t, 17, out.BlockNumber)
+	require.Equal(t, uint64(21000), out.GasUsed.Uint64())
+
+	// waiting on any hash of the chain returns the same outcome
+	again, err := tracker.Wait(ctx, speedUp)
+	require.NoError(t, err)
+	require.Equal(t, out, again)
+
+	for _, hash := range []string{original, speedUp, cancel} {
+		_, err := srv.WaitForMessage(time.Second, "activeTransaction", "unwatch", hash)
+		require.NoError(t, err)
+	}
+}
+
+func TestTxTrackerClose(t *testing.T) {
+	cl, _ := newFakeClient(t, Opts{})
+	tracker := cl.NewTxTracker()
+	require.NoError(t, tracker.Track(context.Background(), "0x01"))
+	errc := make(chan error, 1)
+	go func() {
+		_, err := tracker.Wait(context.Background(), "0x01")
+		errc <- err
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := tracker.Wait(ctx, "0x01")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	tracker.Close()
+	require.Empty(t, cl.Subscriptions())
+	select {
+	case err := <-errc:
+		require.ErrorIs(t, err, ErrTrackerClosed)
+	case <-time.After(time.Second):
+		t.Fatal("Wait did not return after Close")
+	}
+	_, err = tracker.Wait(context.Background(), "0x02")
+	require.ErrorIs(t, err, ErrTrackerClosed)
+}
+
+func TestTxTrackerRetention(t *testing.T) {
+	defer func(d time.Duration) { SettledTxRetention = d }(SettledTxRetention)
+	SettledTxRetention = 20 * time.Millisecond
+	cl, srv := newFakeClient(t, Opts{})
+	tracker := cl.NewTxTracker()
+	defer tracker.Close()
+	require.NoError(t, tracker.Track(context.Background(), "0x01"))
+	require.NoError(t, srv.SendTx(blocknativetest.TxEvent{EventCode: "txSpeedUp", Transaction: map[string]interface{}{"hash": "0x01", "replaceHash": "0x02"}}))
+	_, err := srv.WaitForMessage(time.Second, "activeTransaction", "txSent", "0x02")
+	require.NoError(t, err)
+	require.NoError(t, srv.SendTx(blocknativetest.TxEvent{EventCode: "txConfirmed", Transaction: map[string]interface{}{"hash": "0x02"}}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	out, err := tracker.Wait(ctx, "0x01")
+	require.NoError(t, err)
+	require.Equal(t, TxConfirmed, out.State)
+
+	// every hash of the settled transaction is forgotten after the retention period
+	require.Eventually(t, func() bool {
+		_, original := tracker.State("0x01")
+		_, replacement := tracker.State("0x02")
+		return !original && !replacement
+	}, time.Second, time.Millisecond)
+}
+
+func TestTxTrackerExistingSubscription(t *testing.T) {
+	cl, _ := newFakeClient(t, Opts{})
+	sub, err := cl.NewTransactionSubscription(context.Background(), "0x01")
+	require.NoError(t, err)
+	tracker := cl.NewTxTracker()
+	defer tracker.Close()
+
+	// the caller's subscription is neither replaced nor closed
+	require.ErrorIs(t, tracker.Track(context.Background(), "0x01"), ErrAlreadySubscribed)
+	_, ok := tracker.State("0x01")
+	require.False(t, ok)
+	current, ok := cl.Subscription("0x01")
+	require.True(t, ok)
+	require.Equal(t, AnySubscription(sub), current)
+	select {
+	case <-sub.closed():
+		t.Fatal("the caller's subscription was closed")
+	default:
+	}
+}
@@ -2,12 +2,14 @@ package client
 
 import (
 	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
 )
 
 // BaseMessage is the base message required for all interactions with the websockets api
 type BaseMessage struct {
 	CategoryCode string    `json:"categoryCode"`
-	EventCode    string    `json:"eventCode"`
+	EventCode    EventCode `json:"eventCode"`
 	Timestamp    time.Time `json:"timeStamp"`
 	DappID       string    `json:"dappId"` // api key
 	Version      string    `json:"version"`
@@ -53,34 +55,51 @@ type EthTxPayload struct {
 		BaseMessage
 		Transaction struct {
 			Type                 int       `json:"type"`
-			MaxFeePerGas         string    `json:"maxFeePerGas"`
-			MaxPriorityFeePerGas string    `json:"maxPriorityFeePerGas"`
-			BaseFeePerGas        string    `json:"baseFeePerGas"`
+			MaxFeePerGas         BigInt    `json:"maxFeePerGas"`
+			MaxPriorityFeePerGas BigInt    `json:"maxPriorityFeePerGas"`
+			BaseFeePerGas        BigInt    `json:"baseFeePerGas"`
 			TimeStamp            time.Time `json:"timeStamp"`
-			Status               string    `json:"status"`
+			Status               TxStatus  `json:"status"`
 			MonitorID            string    `json:"monitorId"`
 			MonitorVersion       string    `json:"monitorVersion"`
 			TimePending          string    `json:"timePending"`
 			PendingTimeStamp     time.Time `json:"pendingTimeStamp"`
 			BlocksPending        int       `json:"blocksPending"`
 			Hash                 string    `json:"hash"`
-			From                 string    `json:"from"`
-			To                   string    `json:"to"`
-			Value                string    `json:"value"`
-			Gas                  int       `json:"gas"`
-			GasPrice             string    `json:"gasPrice"`
-			GasPriceGwei         int       `json:"gasPriceGwei"`
-			Nonce                int       `json:"nonce"`
-			BlockHash            string    `json:"blockHash"`
-			BlockNumber          int       `json:"blockNumber"`
-			TransactionIndex     int       `json:"transactionIndex"`
-			Input                string    `json:"input"`
-			GasUsed              string    `json:"gasUsed"`
-			Asset                string    `json:"asset"`
-			WatchedAddress       string    `json:"watchedAddress"`
-			Direction            string    `json:"direction"`
-			Counterparty         string    `json:"counterparty"`
+			// ReplaceHash is the hash of the transaction replacing this one
+			// in txSpeedUp and txCancel events
+			ReplaceHash      string `json:"replaceHash,omitempty"`
+			From             string `json:"from"`
+			To               string `json:"to"`
+			Value            BigInt `json:"value"`
+			Gas              BigInt `json:"gas"`
+			GasPrice         BigInt `json:"gasPrice"`
+			GasPriceGwei     BigInt `json:"gasPriceGwei"`
+			Nonce            BigInt `json:"nonce"`
+			BlockHash        string `json:"blockHash"`
+			BlockNumber      int    `json:"blockNumber"`
+			TransactionIndex int    `json:"transactionIndex"`
+			Input            string `json:"input"`
+			// AccessList is set for access list and dynamic fee transactions
+			AccessList types.AccessList `json:"accessList,omitempty"`
+			// V, R and S are the signature values of the transaction
+			V              BigInt `json:"v"`
+			R              BigInt `json:"r"`
+			S              BigInt `json:"s"`
+			GasUsed        BigInt `json:"gasUsed"`
+			Asset          string `json:"asset"`
+			WatchedAddress string `json:"watchedAddress"`
+			Direction      string `json:"direction"`
+			Counterparty   string `json:"counterparty"`
+			// NetBalanceChanges and InternalTransactions are set for simulated transactions
+			NetBalanceChanges    []NetBalanceChange    `json:"netBalanceChanges,omitempty"`
+			InternalTransactions []InternalTransaction `json:"internalTransactions,omitempty"`
 		} `json:"transaction"`
+		// ContractCall is set when the config that matched the transaction includes an ABI
+		ContractCall *ContractCall `json:"contractCall,omitempty"`
+		// Call is the input of the transaction decoded locally with the ABI of
+		// the config the subscription was created with
+		Call *DecodedCall `json:"-"`
 	} `json:"event"`
 }
 
@@ -95,15 +114,17 @@ type Configuration struct {
 type Config struct {
 	//  valid Ethereum address or 'global'
 	Scope string `json:"scope"`
-	// A slice of valid filters (jsql: https://github.com/deitch/searchjs)
-	Filters []map[string]string `json:"filters,omitempty"`
+	// A slice of valid filters (jsql: https://github.com/deitch/searchjs),
+	// build them with Filter and AddFilter
+	Filters []map[string]interface{} `json:"filters,omitempty"`
 	// JSON abis
 	ABI interface{} `json:"abi,omitempty"`
 	// defines whether the service should automatically watch the address as defined in
 	WatchAddress bool `json:"watchAddress,omitempty"`
 }
 
-// NewConfig returns a new config instance
+// NewConfig returns a new config instance. abis may be an abi.ABI, its JSON
+// encoding or any value encoding to it, see Config.Contract
 func NewConfig(scope string, watchAddress bool, abis interface{}) Config {
 	cfg := Config{
 		Scope:        scope,
@@ -119,7 +140,7 @@ func NewConfig(scope string, watchAddress bool, abis interface{}) Config {
 // NewConfiguration constructs a new configuration message
 func NewConfiguration(msg BaseMessage, config Config) Configuration {
 	msg.CategoryCode = "configs"
-	msg.EventCode = "put"
+	msg.EventCode = EventPut
 	return Configuration{
 		BaseMessage: msg,
 		Config:      config,
@@ -129,7 +150,7 @@ func NewConfiguration(msg BaseMessage, config Config) Configuration {
 // NewEventUnsubscribe constructs an Event unsubscribe message
 func NewEventUnsubscribe(msg BaseMessage, config Config) Configuration {
 	msg.CategoryCode = "configs"
-	msg.EventCode = "unwatch"
+	msg.EventCode = EventUnwatch
 	return Configuration{
 		BaseMessage: msg,
 		Config:      config,
@@ -139,7 +160,7 @@ func NewEventUnsubscribe(msg BaseMessage, config Config) Configuration {
 // NewTxSubscribe constructs a Transaction subscription message
 func NewTxSubscribe(msg BaseMessage, txHash string) TxSubscribe {
 	msg.CategoryCode = "activeTransaction"
-	msg.EventCode = "txSent"
+	msg.EventCode = EventTxSent
 	return TxSubscribe{
 		BaseMessage: msg,
 		Transaction: Transaction{Hash: txHash},
@@ -149,7 +170,7 @@ func NewTxSubscribe(msg BaseMessage, txHash string) TxSubscribe {
 // NewTxUnsubscribe constructs a Transaciton unsubscribe message
 func NewTxUnsubscribe(msg BaseMessage, txHash string) TxSubscribe {
 	msg.CategoryCode = "activeTransaction"
-	msg.EventCode = "unwatch"
+	msg.EventCode = EventUnwatch
 	return TxSubscribe{
 		BaseMessage: msg,
 		Transaction: Transaction{Hash: txHash},
@@ -159,7 +180,7 @@ func NewTxUnsubscribe(msg BaseMessage, txHash string) TxSubscribe {
 // NewAddressSubscribe constructs a address subscription message
 func NewAddressSubscribe(msg BaseMessage, address string) AddressSubscribe {
 	msg.CategoryCode = "accountAddress"
-	msg.EventCode = "watch"
+	msg.EventCode = EventWatch
 	return AddressSubscribe{
 		BaseMessage: msg,
 		Account:     Account{Address: address},
@@ -169,7 +190,7 @@ func NewAddressSubscribe(msg BaseMessage, address string) AddressSubscribe {
 // NewAddressUnsubscribe constructs a address unsubscribe message
 func NewAddressUnsubscribe(msg BaseMessage, address string) AddressSubscribe {
 	msg.CategoryCode = "accountAddress"
-	msg.EventCode = "unwatch"
+	msg.EventCode = EventUnwatch
 	return AddressSubscribe{
 		BaseMessage: msg,
 		Account:     Account{Address: address},
@@ -0,0 +1,127 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// DefaultConfirmationPoll is used when WaitOpts.PollInterval is zero
+const DefaultConfirmationPoll = time.Second
+
+// BlockNumberReader reports the number of the latest block, as
+// ethclient.Client does
+type BlockNumberReader interface {
+	BlockNumber(ctx context.Context) (uint64, error)
+}
+
+// WaitOpts configures WaitForTx
+type WaitOpts struct {
+	// Confirmations is the number of blocks, counting the one including the
+	// transaction, to wait for after blocknative reports it confirmed.
+	// Values above one need Chain
+	Confirmations uint64
+	// Chain is polled for the latest block number while waiting for confirmations
+	Chain BlockNumberReader
+	// PollInterval is how often Chain is polled, DefaultConfirmationPoll if zero
+	PollInterval time.Duration
+	// Timeout bounds the whole wait, zero waits until ctx is done
+	Timeout time.Duration
+	// Subscribe chooses the network the transaction is watched on. It
+	// defaults to the network of the transaction's chain id if known, and
+	// otherwise to the network the client was initialized with
+	Subscribe []SubscribeOption
+}
+
+// TxResult is how a transaction awaited by WaitForTx settled. Check State,
+// as failed and dropped transactions are results too
+type TxResult struct {
+	TxOutcome
+	// BlocksPending and TimePending are how long the settled transaction was pending
+	BlocksPending int
+	TimePending   time.Duration
+	// Confirmations is the number of blocks seen including the transaction,
+	// set when WaitOpts.Confirmations is above one
+	Confirmations uint64
+}
+
+// WaitForTx waits until blocknative reports tx, or a transaction replacing
+// it, confirmed, failed or dropped, then waits for opts.Confirmations blocks
+// if it was confirmed. The subscriptions made for tx are removed before it returns
+func (c *Client) WaitForTx(ctx context.Context, tx *types.Transaction, opts WaitOpts) (*TxResult, error) {
+	if opts.Confirmations > 1 && opts.Chain == nil {
+		return nil, errors.New("waiting for confirmations needs WaitOpts.Chain")
+	}
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+	subOpts := opts.Subscribe
+	if len(subOpts) == 0 {
+		if id := tx.ChainId(); id.Sign() > 0 && id.IsInt64() {
+			if _, err := NetworkByChainID(id.Int64()); err == nil {
+				subOpts = []SubscribeOption{OnChain(id.Int64())}
+			}
+		}
+	}
+	tracker := c.NewTxTracker(subOpts...)
+	defer tracker.Close()
+	out, err := tracker.Wait(ctx, tx.Hash().Hex())
+	if err != nil {
+		return nil, err
+	}
+	res := &TxResult{
+		TxOutcome:     *out,
+		BlocksPending: out.Event.Event.Transaction.BlocksPending,
+		TimePending:   parseTimePending(out.Event.Event.Transaction.TimePending),
+	}
+	if out.State != TxConfirmed || opts.Confirmations <= 1 {
+		return res, nil
+	}
+	if res.Confirmations, err = awaitConfirmations(ctx, opts, uint64(out.BlockNumber)); err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
+// awaitConfirmations polls opts.Chain until the block at height included
+// has opts.Confirmations-1 blocks on top of it
+func awaitConfirmations(ctx context.Context, opts WaitOpts, included uint64) (uint64, error) {
+	interval := opts.PollInterval
+	if interval <= 0 {
+		interval = DefaultConfirmationPoll
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		head, err := opts.Chain.BlockNumber(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("failed to read block number: %w", err)
+		}
+		if head >= included {
+			if seen := head - included + 1; seen >= opts.Confirmations {
+				return seen, nil
+			}
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+}
+
+// parseTimePending parses the milliseconds blocknative reports a
+// transaction was pending for, zero if it is missing or malformed
+func parseTimePending(ms string) time.Duration {
+	n, err := strconv.ParseInt(ms, 10, 64)
+	if err != nil || n < 0 {
+		return 0
+	}
+	return time.Duration(n) * time.Millisecond
+}
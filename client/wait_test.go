@@ -0,0 +1,92 @@
+package client
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ATMackay/go-blocknative/client/blocknativetest"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeChain is a chain whose head advances by one block every time it is read
+type fakeChain struct {
+	mtx  sync.Mutex
+	head uint64
+}
+
+func (c *fakeChain) BlockNumber(context.Context) (uint64, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.head++
+	return c.head, nil
+}
+
+func signedTx(t *testing.T, chainID int64) *types.Transaction {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	to := common.HexToAddress("0xfa6de2697d59e88ed7fc4dfe5a33dac43565ea41")
+	tx, err := types.SignNewTx(key, types.LatestSignerForChainID(big.NewInt(chainID)), &types.DynamicFeeTx{
+		ChainID:   big.NewInt(chainID),
+		Gas:       21000,
+		GasFeeCap: big.NewInt(30e9),
+		GasTipCap: big.NewInt(1e9),
+		To:        &to,
+		Value:     big.NewInt(1),
+	})
+	require.NoError(t, err)
+	return tx
+}
+
+func TestWaitForTx(t *testing.T) {
+	cl, srv := newFakeClient(t, Opts{})
+	tx := signedTx(t, 1)
+	hash := tx.Hash().Hex()
+	go func() {
+		if _, err := srv.WaitForMessage(time.Second, "activeTransaction", "txSent", hash); err != nil {
+			return
+		}
+		srv.SendTx(blocknativetest.TxEvent{EventCode: "txPool", Transaction: map[string]interface{}{"hash": hash}})
+		srv.SendTx(blocknativetest.TxEvent{EventCode: "txConfirmed", Transaction: map[string]interface{}{
+			"hash": hash, "blockNumber": 100, "blocksPending": 2, "timePending": "13742",
+		}})
+	}()
+
+	chain := &fakeChain{head: 98}
+	res, err := cl.WaitForTx(context.Background(), tx, WaitOpts{
+		Confirmations: 3,
+		Chain:         chain,
+		PollInterval:  time.Millisecond,
+		Timeout:       5 * time.Second,
+	})
+	require.NoError(t, err)
+	require.Empty(t, cl.Subscriptions())
+	require.Equal(t, TxConfirmed, res.State)
+	require.Equal(t, hash, res.FinalHash)
+	require.Equal(t, 2, res.BlocksPending)
+	require.Equal(t, 13742*time.Millisecond, res.TimePending)
+	require.Equal(t, uint64(3), res.Confirmations)
+	_, err = srv.WaitForMessage(time.Second, "activeTransaction", "unwatch", hash)
+	require.NoError(t, err)
+
+	_, err = cl.WaitForTx(context.Background(), tx, WaitOpts{Confirmations: 2})
+	require.Error(t, err)
+}
+
+func TestWaitForTxTimeout(t *testing.T) {
+	cl, srv := newFakeClient(t, Opts{})
+	tx := signedTx(t, 137)
+	_, err := cl.WaitForTx(context.Background(), tx, WaitOpts{Timeout: 20 * time.Millisecond})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Empty(t, cl.Subscriptions())
+
+	// the transaction was watched on the network of its chain id and is unwatched again
+	msg, err := srv.WaitForMessage(time.Second, "activeTransaction", "unwatch", tx.Hash().Hex())
+	require.NoError(t, err)
+	require.Equal(t, "matic-main", msg.Blockchain.Network)
+}
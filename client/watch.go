@@ -0,0 +1,323 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+)
+
+// DefaultPacing is used for any zero valued Pacing fields
+var DefaultPacing = Pacing{
+	BatchSize: 50,
+	Interval:  time.Second,
+}
+
+// Pacing configures how fast WatchAddresses sends subscribe messages. Up to
+// BatchSize subscriptions are in flight at once and consecutive batches are
+// started Interval apart, which keeps large watch lists under blocknative's
+// rate limits
+type Pacing struct {
+	BatchSize int
+	Interval  time.Duration
+}
+
+func (p Pacing) withDefaults() Pacing {
+	if p.BatchSize <= 0 {
+		p.BatchSize = DefaultPacing.BatchSize
+	}
+	if p.Interval <= 0 {
+		p.Interval = DefaultPacing.Interval
+	}
+	return p
+}
+
+// AddressEvent is an event received by an AddressWatch, tagged with the
+// watched address it was routed by
+type AddressEvent[T any] struct {
+	Address string
+	Event   T
+}
+
+// WatchError reports an error concerning a single address of an AddressWatch
+type WatchError struct {
+	Address string
+	Err     error
+}
+
+func (e *WatchError) Error() string {
+	return e.Address + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *WatchError) Unwrap() error {
+	return e.Err
+}
+
+// AddressWatch is a single stream of events for many watched addresses.
+// Addresses are subscribed in the background, paced according to
+// Opts.Pacing; events for an address flow as soon as its subscription is
+// acknowledged. Errors for individual addresses are delivered on Err as
+// *WatchError and collected in Failed
+type AddressWatch[T any] struct {
+	*Subscription[AddressEvent[T]]
+	c      *Client
+	opts   subscribeOptions
+	system string             // the blockchain system of the addresses
+	cancel context.CancelFunc // stops subscribing
+	ready  chan struct{}
+
+	mtx      sync.Mutex
+	members  map[string]*watchMember[T]
+	failed   map[string]error
+	settled  bool // all addresses have been subscribed or have failed
+	stopping bool
+}
+
+// WatchAddresses watches every address in addresses, delivering their
+// events on a single stream. It returns once the subscriptions have been
+// queued, use Ready to wait until all of them have been acknowledged.
+// The watch ends when ctx is done or it is unsubscribed. All addresses are
+// watched on the same network, see Client.NewAddressSubscription. Addresses
+// the client already watches, directly or through another AddressWatch, are
+// not taken over: they fail with a WatchError wrapping ErrAlreadySubscribed
+func (c *Client) WatchAddresses(ctx context.Context, addresses []string, opts ...SubscribeOption) (*AddressWatch[EthTxPayload], error) {
+	return WatchAddresses[EthTxPayload](ctx, c, addresses, opts...)
+}
+
+// WatchAddresses is like Client.WatchAddresses but decodes events into T
+func WatchAddresses[T any](ctx context.Context, c *Client, addresses []string, opts ...SubscribeOption) (*AddressWatch[T], error) {
+	o, err := newSubscribeOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	system := o.apply(c.baseMessage()).System
+	var queue []string
+	seen := make(map[string]bool)
+	for _, address := range addresses {
+		if address == "" || seen[registryKey(system, address)] {
+			continue
+		}
+		seen[registryKey(system, address)] = true
+		queue = append(queue, address)
+	}
+	if len(queue) == 0 {
+		return nil, errors.New("no addresses to watch")
+	}
+	select {
+	case <-c.done:
+		return nil, c.readErr()
+	default:
+	}
+	runCtx, cancel := context.WithCancel(ctx)
+	w := &AddressWatch[T]{
+		Subscription: NewSubscription[AddressEvent[T]](""),
+		c:            c,
+		opts:         o,
+		system:       system,
+		cancel:       cancel,
+		ready:        make(chan struct{}),
+		members:      make(map[string]*watchMember[T]),
+		failed:       make(map[string]error),
+	}
+	w.setUnsubscribe(w.unsubscribe)
+	go w.run(runCtx, queue)
+	go func() {
+		select {
+		case <-ctx.Done():
+			w.UnsubscribeContext(c.ctx)
+		case <-w.closed():
+		}
+	}()
+	return w, nil
+}
+
+// Ready is closed once every address has been subscribed or has failed.
+// Waiting doesn't hold up the connection, but events not drained meanwhile
+// are dropped once the buffer is full, see ErrEventDropped
+func (w *AddressWatch[T]) Ready() <-chan struct{} {
+	return w.ready
+}
+
+// Watching returns the addresses currently being watched
+func (w *AddressWatch[T]) Watching() []string {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	out := make([]string, 0, len(w.members))
+	for _, m := range w.members {
+		out = append(out, m.address)
+	}
+	return out
+}
+
+// Failed returns the addresses which could not be subscribed and why
+func (w *AddressWatch[T]) Failed() map[string]error {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	out := make(map[string]error, len(w.failed))
+	for address, err := range w.failed {
+		out[address] = err
+	}
+	return out
+}
+
+// run subscribes the queued addresses batch by batch
+func (w *AddressWatch[T]) run(ctx context.Context, queue []string) {
+	defer close(w.ready)
+	pacing := w.c.opts.Pacing.withDefaults()
+	for start := 0; start < len(queue); start += pacing.BatchSize {
+		if start > 0 {
+			select {
+			case <-time.After(pacing.Interval):
+			case <-ctx.Done():
+			}
+		}
+		end := start + pacing.BatchSize
+		if end > len(queue) {
+			end = len(queue)
+		}
+		var wg sync.WaitGroup
+		for _, address := range queue[start:end] {
+			if err := ctx.Err(); err != nil {
+				w.reject(address, err)
+				continue
+			}
+			wg.Add(1)
+			go func(address string) {
+				defer wg.Done()
+				w.add(ctx, address)
+			}(address)
+		}
+		wg.Wait()
+	}
+	w.mtx.Lock()
+	w.settled = true
+	empty := len(w.members) == 0
+	w.mtx.Unlock()
+	if empty {
+		// nothing is being watched, so no events will ever arrive
+		w.close()
+	}
+}
+
+// add subscribes a single address
+func (w *AddressWatch[T]) add(ctx context.Context, address string) {
+	m := &watchMember[T]{address: address, watch: w, done: make(chan struct{})}
+	base := w.opts.apply(w.c.baseMessage())
+	if err := ValidateAddress(base.Blockchain, address); err != nil {
+		w.reject(address, err)
+		return
+	}
+	if err := w.c.confirmNew(ctx, m, NewAddressSubscribe(base, address), NewAddressUnsubscribe(base, address)); err != nil {
+		w.reject(address, err)
+		return
+	}
+	w.mtx.Lock()
+	if w.stopping {
+		// the watch was unsubscribed while the address was being subscribed
+		w.mtx.Unlock()
+		m.UnsubscribeContext(w.c.ctx)
+		return
+	}
+	w.members[registryKey(w.system, address)] = m
+	w.mtx.Unlock()
+}
+
+// reject records that address could not be subscribed
+func (w *AddressWatch[T]) reject(address string, err error) {
+	w.mtx.Lock()
+	w.failed[address] = err
+	w.mtx.Unlock()
+	w.Subscription.fail(&WatchError{Address: address, Err: err})
+}
+
+// unsubscribe stops subscribing and unwatches every address, returning the first error
+func (w *AddressWatch[T]) unsubscribe(ctx context.Context) error {
+	w.cancel()
+	w.mtx.Lock()
+	w.stopping = true
+	members := make([]*watchMember[T], 0, len(w.members))
+	for _, m := range w.members {
+		members = append(members, m)
+	}
+	w.mtx.Unlock()
+	var first error
+	for _, m := range members {
+		if err := m.UnsubscribeContext(ctx); err != nil && first == nil {
+			first = &WatchError{Address: m.address, Err: err}
+		}
+	}
+	return first
+}
+
+// removed is called when a member stops, the stream is closed once no address is left
+func (w *AddressWatch[T]) removed(m *watchMember[T]) {
+	w.mtx.Lock()
+	if w.members[registryKey(w.system, m.address)] == m {
+		delete(w.members, registryKey(w.system, m.address))
+	}
+	empty := w.settled && len(w.members) == 0
+	w.mtx.Unlock()
+	if empty {
+		w.close()
+	}
+}
+
+// watchMember is the registry entry of a single address of an AddressWatch.
+// It forwards events to the shared stream instead of buffering them itself
+type watchMember[T any] struct {
+	address     string
+	watch       *AddressWatch[T]
+	done        chan struct{}
+	closeOnce   sync.Once
+	unsubOnce   sync.Once
+	unsubErr    error
+	unsubscribe func(ctx context.Context) error
+}
+
+func (m *watchMember[T]) Key() string {
+	return m.address
+}
+
+func (m *watchMember[T]) Unsubscribe() error {
+	return m.UnsubscribeContext(context.Background())
+}
+
+func (m *watchMember[T]) UnsubscribeContext(ctx context.Context) error {
+	m.unsubOnce.Do(func() {
+		if m.unsubscribe != nil {
+			m.unsubErr = m.unsubscribe(ctx)
+		}
+		m.close()
+	})
+	return m.unsubErr
+}
+
+func (m *watchMember[T]) deliver(ctx context.Context, data []byte) {
+	var ev T
+	if err := json.Unmarshal(data, &ev); err != nil {
+		m.fail(err)
+		return
+	}
+	m.watch.emit(AddressEvent[T]{Address: m.address, Event: ev})
+}
+
+func (m *watchMember[T]) fail(err error) {
+	m.watch.Subscription.fail(&WatchError{Address: m.address, Err: err})
+}
+
+func (m *watchMember[T]) close() {
+	m.closeOnce.Do(func() {
+		close(m.done)
+		m.watch.removed(m)
+	})
+}
+
+func (m *watchMember[T]) closed() <-chan struct{} {
+	return m.done
+}
+
+func (m *watchMember[T]) setUnsubscribe(f func(ctx context.Context) error) {
+	m.unsubscribe = f
+}
@@ -0,0 +1,66 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ATMackay/go-blocknative/client/blocknativetest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchAddresses(t *testing.T) {
+	cl, srv := newFakeClient(t, Opts{Pacing: Pacing{BatchSize: 2, Interval: 10 * time.Millisecond}})
+	var addresses []string
+	for i := 1; i <= 5; i++ {
+		addresses = append(addresses, fmt.Sprintf("0x%040x", i))
+	}
+	srv.Reject("accountAddress", addresses[2], "address is not a valid Ethereum address")
+	held, err := cl.NewAddressSubscription(context.Background(), addresses[3])
+	require.NoError(t, err)
+
+	// duplicates are only watched once
+	w, err := cl.WatchAddresses(context.Background(), append(addresses, addresses[0]))
+	require.NoError(t, err)
+	select {
+	case <-w.Ready():
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for subscriptions")
+	}
+	require.Len(t, w.Watching(), 3)
+	require.Len(t, cl.Subscriptions(), 4)
+	failed := w.Failed()
+	require.Len(t, failed, 2)
+	require.ErrorIs(t, failed[addresses[2]], ErrInvalidAddress)
+	// the subscription the caller holds isn't taken over
+	require.ErrorIs(t, failed[addresses[3]], ErrAlreadySubscribed)
+	found, ok := cl.Subscription(addresses[3])
+	require.True(t, ok)
+	require.Equal(t, AnySubscription(held), found)
+	for i := 0; i < 2; i++ {
+		var watchErr *WatchError
+		require.True(t, errors.As(<-w.Err(), &watchErr))
+		require.Contains(t, []string{addresses[2], addresses[3]}, watchErr.Address)
+	}
+
+	for _, address := range []string{addresses[4], addresses[0]} {
+		require.NoError(t, srv.SendTx(blocknativetest.TxEvent{Transaction: map[string]interface{}{"hash": "0x01", "watchedAddress": address}}))
+		select {
+		case ev := <-w.Events():
+			require.Equal(t, address, ev.Address)
+			require.Equal(t, "0x01", ev.Event.Event.Transaction.Hash)
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for event")
+		}
+	}
+
+	require.NoError(t, w.Unsubscribe())
+	_, ok = <-w.Events()
+	require.False(t, ok)
+	require.Equal(t, []string{addresses[3]}, srv.Watched())
+	require.NoError(t, held.Unsubscribe())
+	require.Empty(t, cl.Subscriptions())
+	require.Empty(t, srv.Watched())
+}
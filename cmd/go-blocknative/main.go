@@ -5,8 +5,8 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
-	"time"
 
 	"github.com/ATMackay/go-blocknative/client"
 	"github.com/urfave/cli/v2"
@@ -22,10 +22,11 @@ func main() {
 	app.Usage = "cli for interacting with blocknative api"
 	app.Before = func(c *cli.Context) (err error) {
 		apiClient, err = client.New(c.Context, client.Opts{
-			Scheme: c.String("scheme"),
-			Host:   c.String("host"),
-			Path:   c.String("api.path"),
-			APIKey: c.String("api.key"),
+			Scheme:    c.String("scheme"),
+			Host:      c.String("host"),
+			Path:      c.String("api.path"),
+			APIKey:    c.String("api.key"),
+			Reconnect: c.Bool("reconnect"),
 		})
 		if err != nil {
 			return
@@ -44,6 +45,10 @@ func main() {
 			Usage: "address to use when subscribing to events",
 			Value: "0xfa6de2697D59E88Ed7Fc4dFE5A33daC43565ea41",
 		},
+		&cli.Int64SliceFlag{
+			Name:  "chain.id",
+			Usage: "chain ids of the networks to watch, defaults to mainnet",
+		},
 		&cli.StringFlag{
 			Name:  "tx.hash",
 			Usage: "transaction hash to use when subscribing to events",
@@ -63,6 +68,11 @@ func main() {
 			Usage: "api path to use",
 			Value: "/v0",
 		},
+		&cli.BoolFlag{
+			Name:  "reconnect",
+			Usage: "redial and restore subscriptions when the connection drops",
+			Value: true,
+		},
 	}
 	app.Commands = cli.Commands{
 		&cli.Command{
@@ -75,27 +85,38 @@ func main() {
 					Usage: "subscribe to events based on address",
 					Action: func(c *cli.Context) error {
 						address := c.String("address")
-						apiClient.NewAddressSubscription(address)
-						s := apiClient.SubscriptionRegistry()[address]
-						eventChan := s.Events()
-						go func() {
-							for {
-								e, ok := <-eventChan
-								if !ok {
-									break
-								}
-								ev := e.(client.EthTxPayload)
-								jev, _ := json.Marshal(ev)
-								log.Printf("receive message:\n%v\n", string(jev))
-								time.Sleep(5 * time.Second)
+						// the subscription is unwatched once a shutdown signal is received
+						ctx, stop := signal.NotifyContext(c.Context, syscall.SIGINT, syscall.SIGTERM)
+						defer stop()
+						chainIDs := c.Int64Slice("chain.id")
+						if len(chainIDs) == 0 {
+							chainIDs = []int64{1}
+						}
+						// every network is watched on the same connection, events are merged
+						events := make(chan client.EthTxPayload)
+						var wg sync.WaitGroup
+						for _, id := range chainIDs {
+							s, err := apiClient.NewAddressSubscription(ctx, address, client.OnChain(id))
+							if err != nil {
+								return err
 							}
+							wg.Add(1)
+							go func() {
+								defer wg.Done()
+								for ev := range s.Events() {
+									events <- ev
+								}
+							}()
+						}
+						go func() {
+							wg.Wait()
+							close(events)
 						}()
-						// start the signal handler
-						signalChan := make(chan os.Signal, 1)
-						signal.Notify(signalChan, syscall.SIGINT, syscall.SIGTERM)
-						sig := <-signalChan
-						log.Printf("received shutdown signal '%v', unsubscribing...\n", sig)
-						apiClient.KillSubscription(address)
+						for ev := range events {
+							jev, _ := json.Marshal(ev)
+							log.Printf("receive %s message:\n%v\n", ev.Event.Network, string(jev))
+						}
+						log.Printf("received shutdown signal, unsubscribed\n")
 						log.Printf("bye!\n")
 						return nil
 					},
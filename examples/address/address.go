@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"log"
 	"os"
-	"time"
 
 	"github.com/ATMackay/go-blocknative/client"
 )
@@ -32,30 +31,21 @@ func main() {
 		panic(err)
 	}
 	address := "0xdac17f958d2ee523a2206206994597c13d831ec7"
-	// subscribe to events by address
-	if err := cl.NewAddressSubscription(address); err != nil {
+	// subscribe to events by address, cancelling the context unsubscribes
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s, err := cl.NewAddressSubscription(ctx, address)
+	if err != nil {
 		panic(err)
 	}
-	// read messages in a loop
-	s := cl.SubscriptionRegistry()[address]
-	eventChan := s.Events()
+	// read messages as they arrive
 	var counter int
-	for {
-		if counter == 5 {
-			break
-		}
-		counter++
-		e, ok := <-eventChan
-		if !ok {
-			break
-		}
-		ev := e.(client.EthTxPayload)
+	for ev := range s.Events() {
 		jev, _ := json.MarshalIndent(ev, "", "  ")
 		log.Printf("receive message:\n%v\n", string(jev))
-		time.Sleep(5 * time.Second)
-
+		if counter++; counter == 5 {
+			fmt.Println("unsubscribing")
+			cancel()
+		}
 	}
-	fmt.Println("unsubscribing")
-	cl.KillSubscription(address)
-	time.Sleep(5 * time.Second)
 }
@@ -2,18 +2,16 @@ package main
 
 import (
 	"context"
-	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"log"
+	"math/big"
 	"os"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/ATMackay/go-blocknative/client"
-	"github.com/ethereum/go-ethereum/accounts/abi"
-	"github.com/pkg/errors"
+	"github.com/ethereum/go-ethereum/common"
 )
 
 const (
@@ -45,20 +43,10 @@ func main() {
 
 	exitOnErr(mempMon.Initialize(baseMsg), "initialize subs")
 
-	var abi interface{}
-	exitOnErr(json.Unmarshal([]byte(UsdtABI), &abi), "marshal abi")
-
-	cfgMsg := client.NewConfig(
-		contractAddr,
-		true,
-		abi,
-	)
-	cfgMsg.Filters = []map[string]string{
-		{
-			"contractCall.methodName": methodName,
-			"_propertySearch":         "true",
-		},
-	}
+	// events carry the transfer calls decoded with the abi
+	cfgMsg, err := client.NewABIConfigJSON(contractAddr, true, strings.NewReader(UsdtABI))
+	exitOnErr(err, "load abi")
+	exitOnErr(cfgMsg.AddFilter(client.Filter().Field("contractCall.methodName").Eq(methodName).PropertySearch()), "build filter")
 
 	cfgMsgWithBase := client.NewConfiguration(baseMsg, cfgMsg)
 
@@ -66,64 +54,50 @@ func main() {
 	exitOnErr(err, "config message marshal")
 	log.Println("cfgMsgWithBase", string(msg))
 
-	exitOnErr(mempMon.NewEventSubscription(cfgMsgWithBase), "config subs")
+	// watch the contract for a minute, the config is unwatched once the context expires
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	evSub, err := mempMon.NewEventSubscription(ctx, cfgMsgWithBase)
+	exitOnErr(err, "config subs")
 	log.Print("subscription created   ", "network:", netName, "   contract:", contractAddr, "    method:", methodName)
 
-	evSub := mempMon.SubscriptionRegistry()[cfgMsg.Scope]
-	eventChan := evSub.Events()
-
 	type txIndex struct {
 		blockNumber      int
 		transactionIndex int
-		call             string
+		to               common.Address
+		value            *big.Int
 	}
 
-	indexer := make(chan txIndex)
-
-	var wg sync.WaitGroup
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		var counter int
-		for {
-			time.Sleep(2 * time.Second)
-			if counter > 5 {
-				return
-			}
-			counter++
-			if len(eventChan) < 1 {
-				log.Printf("channel empty\n")
-				continue
-			}
-			e, ok := <-eventChan
-			if !ok {
-				break
-			}
-			ev := e.(client.EthTxPayload)
-			if len(ev.Event.Transaction.Input) < 10 {
-				continue
-			}
-			jev, _ := json.MarshalIndent(ev, "", "  ")
-			log.Printf("msg: %+v \n", string(jev))
-			s, ok := parseInput(ev.Event.Transaction.Input).(string)
-			if !ok {
-				s = "could not parse data"
-			}
-			indexer <- txIndex{blockNumber: ev.Event.Transaction.BlockNumber, transactionIndex: ev.Event.Transaction.TransactionIndex, call: s}
+	var indexer []txIndex
+	for ev := range evSub.Events() {
+		if len(ev.Event.Transaction.Input) < 10 {
+			continue
+		}
+		jev, _ := json.MarshalIndent(ev, "", "  ")
+		log.Printf("msg: %+v \n", string(jev))
+		if cc := ev.Event.ContractCall; cc != nil {
+			// blocknative decodes the call for us since the config includes the abi
+			log.Printf("contract call: %s(%v)\n", cc.MethodName, cc.Params)
 		}
-		return
-	}()
-
-	wg.Wait()
-	evSub.Unsubscribe()
-	for len(indexer) != 0 {
-		tx, ok := <-indexer
-		if !ok {
-			break
+		if ev.Event.Call == nil || ev.Event.Call.Name() != methodName {
+			continue
 		}
+		var transfer struct {
+			To    common.Address
+			Value *big.Int
+		}
+		exitOnErr(ev.Event.Call.Bind(&transfer), "bind transfer arguments")
+		indexer = append(indexer, txIndex{
+			blockNumber:      ev.Event.Transaction.BlockNumber,
+			transactionIndex: ev.Event.Transaction.TransactionIndex,
+			to:               transfer.To,
+			value:            transfer.Value,
+		})
+	}
+
+	for _, tx := range indexer {
 		log.Printf("transaction: %v\n", tx)
 	}
-	time.Sleep(2 * time.Second)
 	err = <-evSub.Err()
 	log.Printf("subscription close message: %v\n", err)
 }
@@ -135,21 +109,3 @@ func exitOnErr(err error, msg string) {
 		os.Exit(1)
 	}
 }
-
-func parseInput(input string) interface{} {
-	abiT, err := abi.JSON(strings.NewReader(UsdtABI))
-	exitOnErr(err, "loading the abi")
-
-	inputData, err := hex.DecodeString(input[10:])
-	exitOnErr(err, "input decode")
-
-	method, exist := abiT.Methods[methodName]
-	if !exist {
-		exitOnErr(errors.New("method doesn't exists in the abi"), "")
-	}
-
-	output, err := method.Inputs.Unpack(inputData)
-	exitOnErr(err, "args unpack")
-
-	return output
-}
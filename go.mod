@@ -5,8 +5,6 @@ go 1.18
 require (
 	github.com/ethereum/go-ethereum v1.10.20
 	github.com/gorilla/websocket v1.5.0
-	github.com/oklog/run v1.1.0
-	github.com/pkg/errors v0.9.1
 	github.com/stretchr/testify v1.8.0
 	github.com/urfave/cli/v2 v2.11.0
 )